@@ -0,0 +1,61 @@
+package azure
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	http_client "azureclient/client/http"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+)
+
+// BlobCacheStore is an http_client.CacheStore keeping responses as JSON
+// blobs, so a cache survives restarts and is shared between instances
+type BlobCacheStore struct {
+	Blobs     IBlobClient
+	Container string
+	Prefix    string // blob name prefix, e.g. "http-cache/member/"
+}
+
+func NewBlobCacheStore(blobs IBlobClient, container, prefix string) *BlobCacheStore {
+	return &BlobCacheStore{Blobs: blobs, Container: container, Prefix: prefix}
+}
+
+func (s *BlobCacheStore) Get(ctx context.Context, key string) (*http_client.CachedResponse, bool, error) {
+	data, err := s.Blobs.DownloadBlob(ctx, s.Container, s.blobName(key))
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var resp http_client.CachedResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, false, err
+	}
+	return &resp, true, nil
+}
+
+func (s *BlobCacheStore) Set(ctx context.Context, key string, resp *http_client.CachedResponse) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	return s.Blobs.UploadBlob(ctx, s.Container, s.blobName(key), data)
+}
+
+func (s *BlobCacheStore) Delete(ctx context.Context, key string) error {
+	err := s.Blobs.DeleteBlob(ctx, s.Container, s.blobName(key))
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return nil
+	}
+	return err
+}
+
+// blobName hashes the key, since URLs are not valid blob names
+func (s *BlobCacheStore) blobName(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return s.Prefix + hex.EncodeToString(sum[:])
+}
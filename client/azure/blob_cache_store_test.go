@@ -0,0 +1,55 @@
+package azure
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	http_client "azureclient/client/http"
+)
+
+func TestBlobCacheStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	blobs := newMemBlobClient()
+	s := NewBlobCacheStore(blobs, "http-cache", "member/")
+	key := "https://members.example.com/members/1?expand=profile"
+
+	if _, ok, err := s.Get(ctx, key); ok || err != nil {
+		t.Fatalf("Get() on an empty store = %v, %v, want a miss", ok, err)
+	}
+	want := &http_client.CachedResponse{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Etag": {`"v1"`}, "Content-Type": {"application/json"}},
+		Body:       []byte(`{"id":1}`),
+		StoredAt:   time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Vary:       map[string]string{"Accept-Language": "th"},
+	}
+	if err := s.Set(ctx, key, want); err != nil {
+		t.Fatal(err)
+	}
+	for name := range blobs.blobs {
+		if !strings.HasPrefix(name, "http-cache/member/") || strings.ContainsAny(strings.TrimPrefix(name, "http-cache/"), "?:") {
+			t.Errorf("blob name %q is not a prefixed hash of the key", name)
+		}
+	}
+	got, ok, err := s.Get(ctx, key)
+	if err != nil || !ok {
+		t.Fatalf("Get() = %v, %v, want a hit", ok, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Get() = %+v, want %+v", got, want)
+	}
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := s.Get(ctx, key); ok {
+		t.Error("entry still cached after Delete")
+	}
+	if err := s.Delete(ctx, key); err != nil {
+		t.Errorf("Delete() of a missing entry = %v, want nil", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
@@ -13,6 +14,10 @@ import (
 type IBlobClient interface {
 	UploadBlob(ctx context.Context, container, blobName string, data []byte) error
 	DownloadBlob(ctx context.Context, container, blobName string) ([]byte, error)
+	// DownloadBlobStream returns the blob's content as a stream along with
+	// its size, or -1 when the size is unknown
+	DownloadBlobStream(ctx context.Context, container, blobName string) (io.ReadCloser, int64, error)
+	DeleteBlob(ctx context.Context, container, blobName string) error
 }
 
 type BlobClient struct {
@@ -51,3 +56,20 @@ func (bc *BlobClient) DownloadBlob(ctx context.Context, container, blobName stri
 	}
 	return buf.Bytes(), nil
 }
+
+func (bc *BlobClient) DownloadBlobStream(ctx context.Context, container, blobName string) (io.ReadCloser, int64, error) {
+	resp, err := bc.Client.DownloadStream(ctx, container, blobName, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	size := int64(-1)
+	if resp.ContentLength != nil {
+		size = *resp.ContentLength
+	}
+	return resp.NewRetryReader(ctx, nil), size, nil
+}
+
+func (bc *BlobClient) DeleteBlob(ctx context.Context, container, blobName string) error {
+	_, err := bc.Client.DeleteBlob(ctx, container, blobName, nil)
+	return err
+}
@@ -0,0 +1,139 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CapturedEmail is a message stored by CaptureEmailClient
+type CapturedEmail struct {
+	ID      string           `json:"id"`
+	SentAt  time.Time        `json:"sentAt"`
+	Request SendEmailRequest `json:"request"`
+}
+
+// CaptureEmailClient keeps sent messages in memory instead of delivering
+// them, for local development and tests
+type CaptureEmailClient struct {
+	mu       sync.RWMutex
+	messages []CapturedEmail
+	MaxKept  int // oldest messages are dropped past this count, 0 keeps all
+}
+
+func NewCaptureEmailClient() *CaptureEmailClient {
+	return &CaptureEmailClient{MaxKept: 500}
+}
+
+func (c *CaptureEmailClient) SendEmail(ctx context.Context, req SendEmailRequest) error {
+	attachments := append([]EmailAttachment(nil), req.Attachments...)
+	if err := validateAttachments(attachments); err != nil {
+		return err
+	}
+	req.Attachments = attachments
+	if _, _, err := parseAddresses(req); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = append(c.messages, CapturedEmail{ID: uuid.New().String(), SentAt: time.Now(), Request: req})
+	if c.MaxKept > 0 && len(c.messages) > c.MaxKept {
+		c.messages = c.messages[len(c.messages)-c.MaxKept:]
+	}
+	return nil
+}
+
+// Messages returns a copy of the captured messages, oldest first
+func (c *CaptureEmailClient) Messages() []CapturedEmail {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]CapturedEmail(nil), c.messages...)
+}
+
+// Find returns the captured messages sent to recipient
+func (c *CaptureEmailClient) Find(recipient string) []CapturedEmail {
+	var found []CapturedEmail
+	for _, m := range c.Messages() {
+		if strings.EqualFold(m.Request.Recipient, recipient) {
+			found = append(found, m)
+		}
+	}
+	return found
+}
+
+// Reset drops all captured messages
+func (c *CaptureEmailClient) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = nil
+}
+
+func (c *CaptureEmailClient) get(id string) (CapturedEmail, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, m := range c.messages {
+		if m.ID == id {
+			return m, true
+		}
+	}
+	return CapturedEmail{}, false
+}
+
+// Handler serves the captured messages under prefix:
+//
+//	GET    {prefix}/              HTML inbox
+//	GET    {prefix}/messages      JSON list
+//	GET    {prefix}/messages/{id} HTML body of one message
+//	DELETE {prefix}/messages      clear the inbox
+func (c *CaptureEmailClient) Handler(prefix string) http.Handler {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := strings.TrimPrefix(r.URL.Path, prefix)
+		switch {
+		case p == "/messages" && r.Method == http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(c.Messages())
+		case p == "/messages" && r.Method == http.MethodDelete:
+			c.Reset()
+			w.WriteHeader(http.StatusNoContent)
+		case strings.HasPrefix(p, "/messages/") && r.Method == http.MethodGet:
+			m, ok := c.get(strings.TrimPrefix(p, "/messages/"))
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			if m.Request.HTML != "" {
+				w.Write([]byte(m.Request.HTML))
+				return
+			}
+			w.Write([]byte("<pre>" + template.HTMLEscapeString(m.Request.PlainText) + "</pre>"))
+		case (p == "" || p == "/") && r.Method == http.MethodGet:
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			inboxTemplate.Execute(w, struct {
+				Prefix   string
+				Messages []CapturedEmail
+			}{prefix, c.Messages()})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+var inboxTemplate = template.Must(template.New("inbox").Funcs(template.FuncMap{
+	"count": func(atts []EmailAttachment) string { return strconv.Itoa(len(atts)) },
+}).Parse(`<!DOCTYPE html>
+<html><head><title>Captured email</title>
+<style>body{font-family:sans-serif}td,th{padding:4px 8px;text-align:left}</style></head>
+<body><h1>Captured email ({{len .Messages}})</h1>
+<table><tr><th>Sent</th><th>From</th><th>To</th><th>Subject</th><th>Attachments</th></tr>
+{{range .Messages}}<tr><td>{{.SentAt.Format "2006-01-02 15:04:05"}}</td><td>{{.Request.Sender}}</td><td>{{.Request.Recipient}}</td>
+<td><a href="{{$.Prefix}}/messages/{{.ID}}">{{.Request.Subject}}</a></td><td>{{count .Request.Attachments}}</td></tr>
+{{end}}</table></body></html>`))
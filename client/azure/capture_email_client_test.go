@@ -0,0 +1,65 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCaptureEmailClientInbox(t *testing.T) {
+	ctx := context.Background()
+	inbox := NewCaptureEmailClient()
+	inbox.MaxKept = 2
+
+	// Code under test only sees an ISendEmailClient
+	var client ISendEmailClient = inbox
+	for _, to := range []string{"ada@example.com", "alan@example.com", "Ada@Example.com"} {
+		if err := client.SendEmail(ctx, SendEmailRequest{Sender: "noreply@example.com", Recipient: to, Subject: "Welcome", PlainText: "Hi"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := client.SendEmail(ctx, SendEmailRequest{Sender: "noreply@example.com", Recipient: "not an address", Subject: "Welcome"}); err == nil {
+		t.Fatal("invalid recipient was captured")
+	}
+
+	if got := inbox.Messages(); len(got) != 2 || got[0].Request.Recipient != "alan@example.com" {
+		t.Fatalf("Messages() = %+v, want the two newest", got)
+	}
+	found := inbox.Find("ada@example.com")
+	if len(found) != 1 || found[0].Request.Subject != "Welcome" {
+		t.Fatalf("Find() = %+v, want the case-insensitive match", found)
+	}
+
+	srv := httptest.NewServer(inbox.Handler("/dev/mail"))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/dev/mail/messages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var listed []CapturedEmail
+	json.NewDecoder(resp.Body).Decode(&listed)
+	resp.Body.Close()
+	if len(listed) != 2 {
+		t.Fatalf("GET /messages listed %d messages, want 2", len(listed))
+	}
+	resp, err = http.Get(srv.URL + "/dev/mail/messages/" + found[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Fatalf("GET /messages/{id} = %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, srv.URL+"/dev/mail/messages", nil)
+	if resp, err = http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if len(inbox.Messages()) != 0 {
+		t.Fatal("DELETE /messages did not clear the inbox")
+	}
+}
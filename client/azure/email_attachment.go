@@ -0,0 +1,119 @@
+package azure
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ACS enforces a single 10MB limit on the send request, measured on the JSON
+// payload in which attachments are base64 encoded. The per-attachment check
+// only rejects content whose encoding alone is over the limit; the JSON
+// envelope adds a few hundred bytes more, so an attachment close to
+// maxAttachmentSize can still fail the whole-message check in BuildPayload.
+const (
+	maxMessageSize = 10 * 1024 * 1024 // encoded size of the whole request
+	// maxAttachmentSize is the largest raw attachment whose base64 encoding
+	// on its own fits in maxMessageSize
+	maxAttachmentSize = maxMessageSize / 4 * 3
+)
+
+// NewAttachmentFromBytes builds an attachment from raw content, detecting the
+// content type from the file name and falling back to content sniffing
+func NewAttachmentFromBytes(name string, data []byte) (EmailAttachment, error) {
+	if base64.StdEncoding.EncodedLen(len(data)) > maxMessageSize {
+		return EmailAttachment{}, errAttachmentTooLarge(name)
+	}
+	return EmailAttachment{
+		Name:            name,
+		ContentType:     detectContentType(name, data),
+		ContentInBase64: base64.StdEncoding.EncodeToString(data),
+	}, nil
+}
+
+// NewAttachmentFromReader reads r fully and builds an attachment from it.
+// Reading stops one byte past the size limit so oversized streams fail early.
+func NewAttachmentFromReader(name string, r io.Reader) (EmailAttachment, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxAttachmentSize+1))
+	if err != nil {
+		return EmailAttachment{}, fmt.Errorf("failed to read attachment %s: %w", name, err)
+	}
+	return NewAttachmentFromBytes(name, data)
+}
+
+// NewAttachmentFromFile builds an attachment from a file on disk, named after the file
+func NewAttachmentFromFile(filePath string) (EmailAttachment, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return EmailAttachment{}, err
+	}
+	defer f.Close()
+	return NewAttachmentFromReader(filepath.Base(filePath), f)
+}
+
+// NewAttachmentFromBlob downloads a blob and builds an attachment from it,
+// named after the blob. Blobs whose reported size is over the limit are
+// rejected before any content is read.
+func NewAttachmentFromBlob(ctx context.Context, blobClient IBlobClient, container, blobName string) (EmailAttachment, error) {
+	body, size, err := blobClient.DownloadBlobStream(ctx, container, blobName)
+	if err != nil {
+		return EmailAttachment{}, fmt.Errorf("failed to download attachment %s/%s: %w", container, blobName, err)
+	}
+	defer body.Close()
+	name := path.Base(blobName)
+	if size > maxAttachmentSize {
+		return EmailAttachment{}, errAttachmentTooLarge(name)
+	}
+	return NewAttachmentFromReader(name, body)
+}
+
+// Inline marks the attachment as an inline image referenced from the HTML
+// body as <img src="cid:contentID">
+func (a EmailAttachment) Inline(contentID string) EmailAttachment {
+	a.ContentID = contentID
+	return a
+}
+
+func detectContentType(name string, data []byte) string {
+	if ct := mime.TypeByExtension(strings.ToLower(filepath.Ext(name))); ct != "" {
+		return ct
+	}
+	return http.DetectContentType(data)
+}
+
+func errAttachmentTooLarge(name string) error {
+	return fmt.Errorf("attachment %s exceeds the 10MB message limit once base64 encoded", name)
+}
+
+// validateAttachments checks the encoding and per-attachment size limit and
+// fills in missing content types
+func validateAttachments(attachments []EmailAttachment) error {
+	contentIDs := map[string]bool{}
+	for i, att := range attachments {
+		if len(att.ContentInBase64) > maxMessageSize {
+			return errAttachmentTooLarge(att.Name)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(att.ContentInBase64)
+		if err != nil {
+			return errors.New("invalid base64 in attachment: " + att.Name)
+		}
+		if att.ContentType == "" {
+			attachments[i].ContentType = detectContentType(att.Name, decoded)
+		}
+		if att.ContentID != "" {
+			if contentIDs[att.ContentID] {
+				return errors.New("duplicate attachment contentId: " + att.ContentID)
+			}
+			contentIDs[att.ContentID] = true
+		}
+	}
+	return nil
+}
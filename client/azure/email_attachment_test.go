@@ -0,0 +1,160 @@
+package azure
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+)
+
+// memBlobClient keeps blobs in memory and counts the bytes read from them
+type memBlobClient struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+	read  int
+	// size overrides the reported size of streamed blobs when non-zero
+	size int64
+}
+
+func newMemBlobClient() *memBlobClient {
+	return &memBlobClient{blobs: map[string][]byte{}}
+}
+
+func (c *memBlobClient) UploadBlob(ctx context.Context, container, blobName string, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.blobs[container+"/"+blobName] = bytes.Clone(data)
+	return nil
+}
+
+func (c *memBlobClient) DownloadBlob(ctx context.Context, container, blobName string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.blobs[container+"/"+blobName]
+	if !ok {
+		return nil, errBlobNotFound
+	}
+	c.read += len(data)
+	return bytes.Clone(data), nil
+}
+
+func (c *memBlobClient) DownloadBlobStream(ctx context.Context, container, blobName string) (io.ReadCloser, int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.blobs[container+"/"+blobName]
+	if !ok {
+		return nil, 0, errBlobNotFound
+	}
+	size := int64(len(data))
+	if c.size != 0 {
+		size = c.size
+	}
+	return io.NopCloser(&countingReader{r: bytes.NewReader(data), c: c}), size, nil
+}
+
+func (c *memBlobClient) DeleteBlob(ctx context.Context, container, blobName string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.blobs, container+"/"+blobName)
+	return nil
+}
+
+// errBlobNotFound is what the storage SDK returns for a missing blob
+var errBlobNotFound = &azcore.ResponseError{StatusCode: http.StatusNotFound, ErrorCode: string(bloberror.BlobNotFound)}
+
+type countingReader struct {
+	r io.Reader
+	c *memBlobClient
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.c.mu.Lock()
+	r.c.read += n
+	r.c.mu.Unlock()
+	return n, err
+}
+
+func TestAttachmentSizeLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		sizes   []int
+		wantErr string
+	}{
+		{name: "small", sizes: []int{1024}},
+		{name: "largest single attachment that fits", sizes: []int{maxAttachmentSize - 4096}},
+		{name: "attachment over the encoded limit", sizes: []int{maxAttachmentSize + 3}, wantErr: "exceeds the 10MB message limit once base64 encoded"},
+		{name: "attachments together over the limit", sizes: []int{maxAttachmentSize / 2, maxAttachmentSize / 2}, wantErr: "once attachments are base64 encoded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := SendEmailRequest{Sender: "noreply@example.com", Recipient: "ada@example.com", Subject: "Report"}
+			var err error
+			for i, size := range tt.sizes {
+				var att EmailAttachment
+				att, err = NewAttachmentFromBytes("report.pdf", bytes.Repeat([]byte{byte(i)}, size))
+				if err != nil {
+					break
+				}
+				req.Attachments = append(req.Attachments, att)
+			}
+			if err == nil {
+				_, err = BuildPayload(req)
+			}
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateAttachmentsUsesEncodedSize(t *testing.T) {
+	att := EmailAttachment{Name: "big.bin", ContentInBase64: strings.Repeat("A", maxMessageSize+4)}
+	if err := validateAttachments([]EmailAttachment{att}); err == nil || !strings.Contains(err.Error(), "big.bin") {
+		t.Fatalf("error = %v, want attachment size error", err)
+	}
+}
+
+func TestNewAttachmentFromBlob(t *testing.T) {
+	ctx := context.Background()
+	blobs := newMemBlobClient()
+	blobs.UploadBlob(ctx, "files", "reports/q1.pdf", []byte("%PDF-1.7"))
+	att, err := NewAttachmentFromBlob(ctx, blobs, "files", "reports/q1.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if att.Name != "q1.pdf" || att.ContentType != "application/pdf" || att.ContentInBase64 != "JVBERi0xLjc=" {
+		t.Fatalf("attachment = %+v", att)
+	}
+
+	// An oversized blob is rejected from its reported size before any content is read
+	blobs = newMemBlobClient()
+	blobs.UploadBlob(ctx, "files", "huge.bin", []byte("x"))
+	blobs.size = maxAttachmentSize + 1
+	if _, err := NewAttachmentFromBlob(ctx, blobs, "files", "huge.bin"); err == nil || !strings.Contains(err.Error(), "huge.bin") {
+		t.Fatalf("error = %v, want attachment size error", err)
+	}
+	if blobs.read != 0 {
+		t.Fatalf("read %d bytes of an oversized blob", blobs.read)
+	}
+
+	// A blob of unknown size is read no further than one byte past the limit
+	blobs = newMemBlobClient()
+	blobs.UploadBlob(ctx, "files", "stream.bin", make([]byte, maxAttachmentSize+1024))
+	blobs.size = -1
+	if _, err := NewAttachmentFromBlob(ctx, blobs, "files", "stream.bin"); err == nil {
+		t.Fatal("oversized blob of unknown size was accepted")
+	}
+	if blobs.read > maxAttachmentSize+1 {
+		t.Fatalf("read %d bytes, want at most %d", blobs.read, maxAttachmentSize+1)
+	}
+}
@@ -0,0 +1,112 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Audit statuses
+const (
+	AuditStatusSent       = "sent"
+	AuditStatusFailed     = "failed"
+	AuditStatusSuppressed = "suppressed"
+	AuditStatusDryRun     = "dry_run"
+)
+
+// SendEmailResult carries identifiers assigned while sending. Attach one to
+// the context with WithSendEmailResult; providers that know them fill it in.
+type SendEmailResult struct {
+	RequestID   string
+	OperationID string
+}
+
+type sendEmailResultKey struct{}
+
+func WithSendEmailResult(ctx context.Context, result *SendEmailResult) context.Context {
+	return context.WithValue(ctx, sendEmailResultKey{}, result)
+}
+
+func sendEmailResultFrom(ctx context.Context) *SendEmailResult {
+	result, _ := ctx.Value(sendEmailResultKey{}).(*SendEmailResult)
+	return result
+}
+
+// EmailAuditEntry describes one SendEmail attempt
+type EmailAuditEntry struct {
+	RequestID    string
+	OperationID  string
+	Sender       string
+	Recipient    string
+	Subject      string
+	TemplateName string
+	Status       string
+	Error        string
+	PlainText    string
+	HTML         string
+	Duration     time.Duration
+}
+
+// EmailAuditor persists audit entries
+type EmailAuditor interface {
+	RecordEmail(ctx context.Context, entry EmailAuditEntry) error
+}
+
+// AuditingEmailClient records every SendEmail attempt made through it.
+// Audit failures are logged and counted but never fail the send, since the
+// email may already have been delivered.
+type AuditingEmailClient struct {
+	Next    ISendEmailClient
+	Auditor EmailAuditor
+}
+
+func NewAuditingEmailClient(next ISendEmailClient, auditor EmailAuditor) *AuditingEmailClient {
+	return &AuditingEmailClient{Next: next, Auditor: auditor}
+}
+
+func (c *AuditingEmailClient) SendEmail(ctx context.Context, req SendEmailRequest) error {
+	if req.RequestID == "" {
+		req.RequestID = uuid.New().String()
+	}
+	result := sendEmailResultFrom(ctx)
+	if result == nil {
+		result = &SendEmailResult{}
+		ctx = WithSendEmailResult(ctx, result)
+	}
+	start := time.Now()
+	err := c.Next.SendEmail(ctx, req)
+
+	entry := EmailAuditEntry{
+		RequestID:    req.RequestID,
+		OperationID:  result.OperationID,
+		Sender:       req.Sender,
+		Recipient:    req.Recipient,
+		Subject:      req.Subject,
+		TemplateName: req.TemplateName,
+		Status:       AuditStatusSent,
+		PlainText:    req.PlainText,
+		HTML:         req.HTML,
+		Duration:     time.Since(start),
+	}
+	switch {
+	case errors.Is(err, ErrDryRun):
+		entry.Status = AuditStatusDryRun
+	case errors.Is(err, ErrRecipientSuppressed):
+		entry.Status = AuditStatusSuppressed
+		entry.Error = err.Error()
+	case err != nil:
+		entry.Status = AuditStatusFailed
+		entry.Error = err.Error()
+	}
+	// Record even if the caller's context was cancelled mid-send
+	if auditErr := c.Auditor.RecordEmail(context.WithoutCancel(ctx), entry); auditErr != nil {
+		slog.Error("Email audit failed", "request_id", entry.RequestID, "status", entry.Status, "err", auditErr)
+		auditFailures.Add(ctx, 1, metric.WithAttributes(attribute.String("email.status", entry.Status)))
+	}
+	return err
+}
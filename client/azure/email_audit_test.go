@@ -0,0 +1,62 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type fakeAuditor struct {
+	entries []EmailAuditEntry
+	err     error
+}
+
+func (a *fakeAuditor) RecordEmail(ctx context.Context, entry EmailAuditEntry) error {
+	a.entries = append(a.entries, entry)
+	return a.err
+}
+
+type fakeEmailClient struct {
+	err error
+}
+
+func (c *fakeEmailClient) SendEmail(ctx context.Context, req SendEmailRequest) error {
+	return c.err
+}
+
+func TestAuditingEmailClientStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		sendErr error
+		want    string
+	}{
+		{name: "sent", want: AuditStatusSent},
+		{name: "failed", sendErr: errors.New("boom"), want: AuditStatusFailed},
+		{name: "suppressed", sendErr: ErrRecipientSuppressed, want: AuditStatusSuppressed},
+		{name: "dry run", sendErr: ErrDryRun, want: AuditStatusDryRun},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auditor := &fakeAuditor{}
+			client := NewAuditingEmailClient(&fakeEmailClient{err: tt.sendErr}, auditor)
+			err := client.SendEmail(context.Background(), SendEmailRequest{Recipient: "ada@example.com"})
+			if !errors.Is(err, tt.sendErr) {
+				t.Fatalf("err = %v, want %v", err, tt.sendErr)
+			}
+			if len(auditor.entries) != 1 || auditor.entries[0].Status != tt.want {
+				t.Fatalf("entries = %+v, want one with status %q", auditor.entries, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuditingEmailClientIgnoresAuditFailure(t *testing.T) {
+	auditor := &fakeAuditor{err: errors.New("database is down")}
+	client := NewAuditingEmailClient(&fakeEmailClient{}, auditor)
+	if err := client.SendEmail(context.Background(), SendEmailRequest{Recipient: "ada@example.com"}); err != nil {
+		t.Fatalf("err = %v, want nil when only the audit fails", err)
+	}
+	if len(auditor.entries) != 1 {
+		t.Fatalf("audit attempted %d times, want 1", len(auditor.entries))
+	}
+}
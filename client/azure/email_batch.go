@@ -0,0 +1,202 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	"azureclient/internal/ratelimit"
+
+	"github.com/google/uuid"
+)
+
+// Batch result statuses
+const (
+	BatchStatusSent    = "sent"
+	BatchStatusFailed  = "failed"
+	BatchStatusPending = "pending"
+	BatchStatusDryRun  = "dry_run" // validated only; sent on a later real run
+)
+
+// BatchRecipient is one recipient of a batch send with its template data
+type BatchRecipient struct {
+	Address string         `json:"address"`
+	Data    map[string]any `json:"data,omitempty"`
+}
+
+// BatchRequest is a templated message sent to many recipients
+type BatchRequest struct {
+	Sender      string
+	Template    EmailTemplate
+	Recipients  []BatchRecipient
+	Attachments []EmailAttachment
+}
+
+// BatchResult is the outcome for one recipient
+type BatchResult struct {
+	Address  string    `json:"address"`
+	Status   string    `json:"status"`
+	Error    string    `json:"error,omitempty"`
+	Attempts int       `json:"attempts"`
+	SentAt   time.Time `json:"sentAt,omitempty"`
+}
+
+// CampaignState tracks per-recipient results so an interrupted campaign can
+// be resumed; it is JSON serializable for persistence between runs
+type CampaignState struct {
+	ID        string                 `json:"id"`
+	Template  string                 `json:"template"`
+	StartedAt time.Time              `json:"startedAt"`
+	Results   map[string]BatchResult `json:"results"`
+
+	mu sync.Mutex
+}
+
+func NewCampaignState(templateName string) *CampaignState {
+	return &CampaignState{
+		ID:        uuid.New().String(),
+		Template:  templateName,
+		StartedAt: time.Now(),
+		Results:   map[string]BatchResult{},
+	}
+}
+
+// Done reports whether the recipient was already sent successfully
+func (s *CampaignState) Done(address string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Results[strings.ToLower(address)].Status == BatchStatusSent
+}
+
+// Counts returns the number of results per status
+func (s *CampaignState) Counts() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := map[string]int{}
+	for _, r := range s.Results {
+		counts[r.Status]++
+	}
+	return counts
+}
+
+// campaignStateJSON is CampaignState without its mutex
+type campaignStateJSON struct {
+	ID        string                 `json:"id"`
+	Template  string                 `json:"template"`
+	StartedAt time.Time              `json:"startedAt"`
+	Results   map[string]BatchResult `json:"results"`
+}
+
+// Snapshot returns a copy of the results, safe to read while a Send is running
+func (s *CampaignState) Snapshot() map[string]BatchResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return maps.Clone(s.Results)
+}
+
+// MarshalJSON encodes a snapshot of the state
+func (s *CampaignState) MarshalJSON() ([]byte, error) {
+	return json.Marshal(campaignStateJSON{ID: s.ID, Template: s.Template, StartedAt: s.StartedAt, Results: s.Snapshot()})
+}
+
+func (s *CampaignState) record(r BatchResult) BatchResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := strings.ToLower(r.Address)
+	r.Attempts = s.Results[key].Attempts + 1
+	s.Results[key] = r
+	return r
+}
+
+// BatchSender fans a BatchRequest out over a bounded worker pool, pacing
+// sends with a token bucket so the ACS quota is not exceeded
+type BatchSender struct {
+	Client  ISendEmailClient
+	Workers int
+	Limiter *ratelimit.TokenBucket
+}
+
+// NewBatchSender creates a BatchSender allowing ratePerMinute sends with the given burst
+func NewBatchSender(client ISendEmailClient, workers, ratePerMinute, burst int) *BatchSender {
+	if workers < 1 {
+		workers = 1
+	}
+	return &BatchSender{
+		Client:  client,
+		Workers: workers,
+		Limiter: ratelimit.NewTokenBucket(ratelimit.PerMinute(ratePerMinute), burst),
+	}
+}
+
+// Send delivers the batch, skipping recipients already sent in state, and
+// returns the results of this run in recipient order. When ctx is cancelled
+// the remaining recipients are reported as pending and state can be passed
+// to a later call to resume.
+func (b *BatchSender) Send(ctx context.Context, req BatchRequest, state *CampaignState) ([]BatchResult, error) {
+	if state == nil {
+		state = NewCampaignState(req.Template.Name)
+	}
+	results := make([]BatchResult, len(req.Recipients))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < b.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = b.sendOne(ctx, req, req.Recipients[i], state)
+			}
+		}()
+	}
+
+	dispatched := 0
+	var dispatchErr error
+dispatch:
+	for i, rcpt := range req.Recipients {
+		if state.Done(rcpt.Address) {
+			results[i] = BatchResult{Address: rcpt.Address, Status: BatchStatusSent}
+			dispatched++
+			continue
+		}
+		if err := b.Limiter.Wait(ctx); err != nil {
+			dispatchErr = err
+			break dispatch
+		}
+		select {
+		case jobs <- i:
+			dispatched++
+		case <-ctx.Done():
+			dispatchErr = ctx.Err()
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i := dispatched; i < len(req.Recipients); i++ {
+		results[i] = BatchResult{Address: req.Recipients[i].Address, Status: BatchStatusPending}
+	}
+	return results, dispatchErr
+}
+
+func (b *BatchSender) sendOne(ctx context.Context, req BatchRequest, rcpt BatchRecipient, state *CampaignState) BatchResult {
+	emailReq, err := req.Template.Request(req.Sender, rcpt.Address, rcpt.Data)
+	if err == nil {
+		emailReq.Attachments = req.Attachments
+		emailReq.TemplateName = req.Template.Name
+		// Resumed campaigns reuse the key so a recipient is never emailed twice
+		emailReq.IdempotencyKey = state.ID + ":" + strings.ToLower(rcpt.Address)
+		err = b.Client.SendEmail(ctx, emailReq)
+	}
+	if errors.Is(err, ErrDryRun) {
+		return state.record(BatchResult{Address: rcpt.Address, Status: BatchStatusDryRun})
+	}
+	if err != nil {
+		return state.record(BatchResult{Address: rcpt.Address, Status: BatchStatusFailed, Error: err.Error()})
+	}
+	return state.record(BatchResult{Address: rcpt.Address, Status: BatchStatusSent, SentAt: time.Now()})
+}
@@ -0,0 +1,126 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"azureclient/internal/ratelimit"
+)
+
+type recordingEmailClient struct {
+	mu   sync.Mutex
+	sent []SendEmailRequest
+	err  error
+}
+
+func (c *recordingEmailClient) SendEmail(ctx context.Context, req SendEmailRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sent = append(c.sent, req)
+	return c.err
+}
+
+func batchRequest(addresses ...string) BatchRequest {
+	req := BatchRequest{Sender: "noreply@example.com", Template: EmailTemplate{Name: "welcome", Subject: "Hi", PlainText: "Hello"}}
+	for _, a := range addresses {
+		req.Recipients = append(req.Recipients, BatchRecipient{Address: a})
+	}
+	return req
+}
+
+func TestBatchSenderLimiterErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     func() (context.Context, context.CancelFunc)
+		wantErr error
+	}{
+		{
+			name: "wait would exceed deadline",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), time.Second)
+			},
+			wantErr: ratelimit.ErrWouldExceedDeadline,
+		},
+		{
+			name: "cancelled while waiting",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(50*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+			wantErr: context.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &recordingEmailClient{}
+			// Two sends up front, then one a minute
+			sender := NewBatchSender(client, 2, 1, 2)
+			state := NewCampaignState("welcome")
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			results, err := sender.Send(ctx, batchRequest("a@example.com", "b@example.com", "c@example.com", "d@example.com"), state)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			want := []string{BatchStatusSent, BatchStatusSent, BatchStatusPending, BatchStatusPending}
+			for i, r := range results {
+				if r.Status != want[i] {
+					t.Errorf("results[%d].Status = %q, want %q", i, r.Status, want[i])
+				}
+			}
+			if len(client.sent) != 2 {
+				t.Errorf("sent %d emails, want 2", len(client.sent))
+			}
+			if state.Done("c@example.com") {
+				t.Error("pending recipient recorded as sent")
+			}
+		})
+	}
+}
+
+func TestBatchSenderResumeSkipsSent(t *testing.T) {
+	client := &recordingEmailClient{}
+	sender := &BatchSender{Client: client, Workers: 1, Limiter: ratelimit.NewTokenBucket(0, 0)}
+	state := NewCampaignState("welcome")
+	req := batchRequest("a@example.com", "B@example.com")
+
+	if _, err := sender.Send(context.Background(), batchRequest("a@example.com"), state); err != nil {
+		t.Fatal(err)
+	}
+	results, err := sender.Send(context.Background(), req, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(client.sent) != 2 || client.sent[1].Recipient != "B@example.com" {
+		t.Fatalf("sent %+v, want a then B", client.sent)
+	}
+	if results[0].Status != BatchStatusSent || results[1].Attempts != 1 {
+		t.Errorf("results = %+v", results)
+	}
+	if want := state.ID + ":b@example.com"; client.sent[1].IdempotencyKey != want {
+		t.Errorf("IdempotencyKey = %q, want %q", client.sent[1].IdempotencyKey, want)
+	}
+}
+
+func TestBatchSenderDryRunIsNotSent(t *testing.T) {
+	acs := NewSendEmailClient("http://127.0.0.1:0", "key")
+	acs.DryRun = true
+	sender := &BatchSender{Client: acs, Workers: 1, Limiter: ratelimit.NewTokenBucket(0, 0)}
+	state := NewCampaignState("welcome")
+
+	results, err := sender.Send(context.Background(), batchRequest("ada@example.com"), state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Status != BatchStatusDryRun {
+		t.Errorf("Status = %q, want %q", results[0].Status, BatchStatusDryRun)
+	}
+	if state.Done("ada@example.com") {
+		t.Error("dry-run recipient recorded as sent")
+	}
+}
@@ -3,11 +3,13 @@ package azure
 import (
 	"bytes"
 	"context"
-	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/mail"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -17,15 +19,38 @@ type ISendEmailClient interface {
 	SendEmail(ctx context.Context, req SendEmailRequest) error
 }
 
+// ErrDryRun is returned by SendEmail in DryRun mode once the request has
+// been validated, so callers can tell it apart from a real send
+var ErrDryRun = errors.New("dry run: email validated but not sent")
+
+// DryRunError is the ErrDryRun returned by SendEmail; it carries the payload
+// that would have been sent
+type DryRunError struct {
+	Payload *SendEmailPayload
+}
+
+func (e *DryRunError) Error() string { return ErrDryRun.Error() }
+
+func (e *DryRunError) Unwrap() error { return ErrDryRun }
+
 type SendEmailClient struct {
-	Endpoint  string
-	AccessKey string
+	Endpoint   string
+	AccessKey  string
+	HTTPClient *http.Client
+	MaxRetries int           // retries on 429 and 5xx responses
+	RetryDelay time.Duration // base delay when the server sends no Retry-After
+	MaxDelay   time.Duration // upper bound for a single wait between attempts
+	DryRun     bool          // validate and build the payload without sending
 }
 
 func NewSendEmailClient(endpoint, accessKey string) *SendEmailClient {
 	return &SendEmailClient{
-		Endpoint:  endpoint,
-		AccessKey: accessKey,
+		Endpoint:   endpoint,
+		AccessKey:  accessKey,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		MaxRetries: 3,
+		RetryDelay: 1 * time.Second,
+		MaxDelay:   30 * time.Second,
 	}
 }
 
@@ -33,6 +58,7 @@ type EmailAttachment struct {
 	Name            string `json:"name"`
 	ContentType     string `json:"contentType"`
 	ContentInBase64 string `json:"contentInBase64"`
+	ContentID       string `json:"contentId,omitempty"` // set for inline images
 }
 
 type SendEmailPayload struct {
@@ -52,29 +78,41 @@ type SendEmailPayload struct {
 
 // SendEmailRequest encapsulates all parameters for sending an email
 type SendEmailRequest struct {
-	Sender      string
-	Recipient   string
-	Subject     string
-	PlainText   string
-	HTML        string
-	Attachments []EmailAttachment
+	Sender       string
+	Recipient    string
+	Subject      string
+	PlainText    string
+	HTML         string
+	Attachments  []EmailAttachment
+	RequestID    string // sent as x-ms-client-request-id, generated when empty
+	TemplateName string // recorded in the audit log
+	// IdempotencyKey is sent as Repeatability-Request-ID so retries of the
+	// same logical email are delivered once; FirstSent defaults to now.
+	// Without a key each SendEmail call uses a random one, so its own
+	// retries are still delivered once.
+	IdempotencyKey string
+	FirstSent      time.Time
 }
 
-// SendEmail sends an email, optionally with attachments and HTML content
-func (c *SendEmailClient) SendEmail(ctx context.Context, req SendEmailRequest) error {
-	const maxAttachmentSize = 10 * 1024 * 1024 // 10MB
-	for _, att := range req.Attachments {
-		decoded, err := base64.StdEncoding.DecodeString(att.ContentInBase64)
-		if err != nil {
-			return errors.New("invalid base64 in attachment: " + att.Name)
-		}
-		if len(decoded) > maxAttachmentSize {
-			return errors.New("attachment exceeds 10MB: " + att.Name)
-		}
+// BuildPayload validates req and returns the payload exactly as it would be
+// sent to ACS, without sending it
+func BuildPayload(req SendEmailRequest) (*SendEmailPayload, error) {
+	attachments := append([]EmailAttachment(nil), req.Attachments...)
+	if err := validateAttachments(attachments); err != nil {
+		return nil, err
+	}
+	if _, err := mail.ParseAddress(req.Sender); err != nil {
+		return nil, fmt.Errorf("invalid sender address %q: %w", req.Sender, err)
+	}
+	if _, err := mail.ParseAddress(req.Recipient); err != nil {
+		return nil, fmt.Errorf("invalid recipient address %q: %w", req.Recipient, err)
+	}
+	if req.Subject == "" {
+		return nil, errors.New("email subject is required")
 	}
-	payload := SendEmailPayload{
+	payload := &SendEmailPayload{
 		SenderAddress: req.Sender,
-		Attachments:   req.Attachments,
+		Attachments:   attachments,
 	}
 	payload.Content.Subject = req.Subject
 	payload.Content.PlainText = req.PlainText
@@ -82,23 +120,123 @@ func (c *SendEmailClient) SendEmail(ctx context.Context, req SendEmailRequest) e
 	payload.Recipients.To = append(payload.Recipients.To, struct {
 		Address string `json:"address"`
 	}{Address: req.Recipient})
-
-	url := c.Endpoint + "/emails:send?api-version=2023-03-31"
 	b, _ := json.Marshal(payload)
-	reqHttp, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(b))
-	if err != nil {
-		return err
+	if len(b) > maxMessageSize {
+		return nil, errors.New("email exceeds the 10MB message limit once attachments are base64 encoded")
+	}
+	return payload, nil
+}
+
+// apiVersion picks the ACS API version; inline attachments (contentId) are
+// only accepted by the newer one
+func (p *SendEmailPayload) apiVersion() string {
+	for _, att := range p.Attachments {
+		if att.ContentID != "" {
+			return "2025-09-01"
+		}
 	}
-	reqHttp.Header.Set("Content-Type", "application/json")
-	reqHttp.Header.Set("Authorization", "Bearer "+c.AccessKey)
-	reqHttp.Header.Set("x-ms-client-request-id", uuid.New().String())
-	resp, err := http.DefaultClient.Do(reqHttp)
+	return "2023-03-31"
+}
+
+// BuildPayload is the dry-run counterpart of SendEmail
+func (c *SendEmailClient) BuildPayload(ctx context.Context, req SendEmailRequest) (*SendEmailPayload, error) {
+	return BuildPayload(req)
+}
+
+// SendEmail sends an email, optionally with attachments and HTML content.
+// In DryRun mode the request is validated but not sent and a *DryRunError
+// holding the payload is returned.
+func (c *SendEmailClient) SendEmail(ctx context.Context, req SendEmailRequest) error {
+	payload, err := BuildPayload(req)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 300 {
-		return fmt.Errorf("email send failed: %s", resp.Status)
+	if c.DryRun {
+		return &DryRunError{Payload: payload}
+	}
+	url := c.Endpoint + "/emails:send?api-version=" + payload.apiVersion()
+	b, _ := json.Marshal(payload)
+	requestID := req.RequestID
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+	result := sendEmailResultFrom(ctx)
+	if result != nil {
+		result.RequestID = requestID
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	repeatabilityID := uuid.New().String()
+	if req.IdempotencyKey != "" {
+		repeatabilityID = repeatabilityRequestID(req.IdempotencyKey)
+	}
+	firstSent := req.FirstSent
+	if firstSent.IsZero() {
+		firstSent = time.Now()
+	}
+	for attempt := 0; ; attempt++ {
+		reqHttp, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(b))
+		if err != nil {
+			return err
+		}
+		reqHttp.Header.Set("Content-Type", "application/json")
+		reqHttp.Header.Set("Authorization", "Bearer "+c.AccessKey)
+		reqHttp.Header.Set("x-ms-client-request-id", requestID)
+		reqHttp.Header.Set("Repeatability-Request-ID", repeatabilityID)
+		reqHttp.Header.Set("Repeatability-First-Sent", firstSent.UTC().Format(http.TimeFormat))
+		resp, err := httpClient.Do(reqHttp)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode < 300 {
+			if result != nil {
+				result.OperationID = operationID(resp)
+			}
+			resp.Body.Close()
+			return nil
+		}
+		emailErr := parseEmailError(resp)
+		resp.Body.Close()
+		if !emailErr.Retryable() || attempt >= c.MaxRetries {
+			return emailErr
+		}
+		if err := c.wait(ctx, attempt, resp.Header); err != nil {
+			return err
+		}
+	}
+}
+
+// operationID reads the long-running operation ID from an accepted send,
+// preferring the response body and falling back to the operation-id header
+func operationID(resp *http.Response) string {
+	var body struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&body); err == nil && body.ID != "" {
+		return body.ID
+	}
+	return resp.Header.Get("operation-id")
+}
+
+// wait sleeps before the next attempt, preferring the server's Retry-After
+// hint over exponential backoff
+func (c *SendEmailClient) wait(ctx context.Context, attempt int, h http.Header) error {
+	delay, ok := retryAfter(h)
+	if !ok {
+		delay = c.RetryDelay << attempt
+	}
+	if c.MaxDelay > 0 && delay > c.MaxDelay {
+		delay = c.MaxDelay
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
 	}
-	return nil
 }
@@ -0,0 +1,209 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// acsServer answers each send with the next scripted response and records
+// the requests it receives
+type acsServer struct {
+	mu        sync.Mutex
+	responses []func(w http.ResponseWriter)
+	requests  []*http.Request
+}
+
+func (s *acsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r)
+	respond := s.responses[0]
+	if len(s.responses) > 1 {
+		s.responses = s.responses[1:]
+	}
+	respond(w)
+}
+
+func reply(status int, header map[string]string, body string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		for k, v := range header {
+			w.Header().Set(k, v)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}
+}
+
+func newTestSendEmailClient(t *testing.T, responses ...func(w http.ResponseWriter)) (*SendEmailClient, *acsServer) {
+	t.Helper()
+	acs := &acsServer{responses: responses}
+	srv := httptest.NewServer(acs)
+	t.Cleanup(srv.Close)
+	c := NewSendEmailClient(srv.URL, "key")
+	c.RetryDelay = time.Millisecond
+	return c, acs
+}
+
+var testEmail = SendEmailRequest{Sender: "noreply@example.com", Recipient: "ada@example.com", Subject: "Hello", PlainText: "Hi"}
+
+func TestSendEmailRetries(t *testing.T) {
+	accepted := reply(http.StatusAccepted, nil, `{"id": "op-1"}`)
+	tests := []struct {
+		name      string
+		responses []func(w http.ResponseWriter)
+		wantSends int
+		wantErr   int // status of the returned EmailError, 0 for success
+		minWait   time.Duration
+	}{
+		{
+			name:      "503 then accepted",
+			responses: []func(w http.ResponseWriter){reply(http.StatusServiceUnavailable, nil, ""), accepted},
+			wantSends: 2,
+		},
+		{
+			name:      "429 honours Retry-After",
+			responses: []func(w http.ResponseWriter){reply(http.StatusTooManyRequests, map[string]string{"retry-after-ms": "150"}, ""), accepted},
+			wantSends: 2,
+			minWait:   150 * time.Millisecond,
+		},
+		{
+			name:      "400 is not retried",
+			responses: []func(w http.ResponseWriter){reply(http.StatusBadRequest, nil, `{"error": {"code": "InvalidRecipient", "message": "bad address"}}`), accepted},
+			wantSends: 1,
+			wantErr:   http.StatusBadRequest,
+		},
+		{
+			name:      "gives up after MaxRetries",
+			responses: []func(w http.ResponseWriter){reply(http.StatusInternalServerError, nil, "boom")},
+			wantSends: 4,
+			wantErr:   http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, acs := newTestSendEmailClient(t, tt.responses...)
+			start := time.Now()
+			err := c.SendEmail(context.Background(), testEmail)
+			if elapsed := time.Since(start); elapsed < tt.minWait {
+				t.Errorf("returned after %v, want at least %v", elapsed, tt.minWait)
+			}
+			var emailErr *EmailError
+			switch {
+			case tt.wantErr == 0 && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != 0 && (!errors.As(err, &emailErr) || emailErr.StatusCode != tt.wantErr):
+				t.Fatalf("error = %v, want EmailError with status %d", err, tt.wantErr)
+			}
+			if len(acs.requests) != tt.wantSends {
+				t.Fatalf("sent %d requests, want %d", len(acs.requests), tt.wantSends)
+			}
+			// Every attempt carries the same repeatability ID so ACS delivers once
+			id := acs.requests[0].Header.Get("Repeatability-Request-ID")
+			if id == "" || acs.requests[0].Header.Get("Repeatability-First-Sent") == "" {
+				t.Fatal("repeatability headers are missing")
+			}
+			for _, r := range acs.requests[1:] {
+				if got := r.Header.Get("Repeatability-Request-ID"); got != id {
+					t.Fatalf("retry used Repeatability-Request-ID %q, want %q", got, id)
+				}
+			}
+		})
+	}
+}
+
+func TestSendEmailDryRunReturnsPayload(t *testing.T) {
+	c, acs := newTestSendEmailClient(t, reply(http.StatusAccepted, nil, ""))
+	c.DryRun = true
+
+	err := c.SendEmail(context.Background(), testEmail)
+	var dryRun *DryRunError
+	if !errors.As(err, &dryRun) || !errors.Is(err, ErrDryRun) {
+		t.Fatalf("err = %v, want a *DryRunError matching ErrDryRun", err)
+	}
+	if got := dryRun.Payload.Content.Subject; got != testEmail.Subject {
+		t.Errorf("Payload subject = %q, want %q", got, testEmail.Subject)
+	}
+	if to := dryRun.Payload.Recipients.To; len(to) != 1 || to[0].Address != testEmail.Recipient {
+		t.Errorf("Payload recipients = %+v, want %s", to, testEmail.Recipient)
+	}
+	if len(acs.requests) != 0 {
+		t.Errorf("sent %d requests in dry-run mode, want 0", len(acs.requests))
+	}
+}
+
+func TestParseEmailError(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		want      EmailError
+		retryable bool
+	}{
+		{
+			name:   "ACS envelope",
+			status: http.StatusBadRequest,
+			body:   `{"error": {"code": "InvalidRecipient", "message": "bad address", "target": "recipients.to"}}`,
+			want:   EmailError{StatusCode: http.StatusBadRequest, Code: "InvalidRecipient", Message: "bad address", Target: "recipients.to", RequestID: "req-1"},
+		},
+		{
+			name:      "body that is not an envelope",
+			status:    http.StatusBadGateway,
+			body:      "upstream unavailable",
+			want:      EmailError{StatusCode: http.StatusBadGateway, Message: "upstream unavailable", RequestID: "req-1"},
+			retryable: true,
+		},
+		{
+			name:      "throttled",
+			status:    http.StatusTooManyRequests,
+			want:      EmailError{StatusCode: http.StatusTooManyRequests, RequestID: "req-1"},
+			retryable: true,
+		},
+		{name: "unauthorized", status: http.StatusUnauthorized, want: EmailError{StatusCode: http.StatusUnauthorized, RequestID: "req-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			rec.Header().Set("x-ms-request-id", "req-1")
+			rec.WriteHeader(tt.status)
+			rec.WriteString(tt.body)
+			got := parseEmailError(rec.Result())
+			if got.StatusCode != tt.want.StatusCode || got.Code != tt.want.Code || got.Message != tt.want.Message ||
+				got.Target != tt.want.Target || got.RequestID != tt.want.RequestID {
+				t.Fatalf("parseEmailError() = %+v, want %+v", *got, tt.want)
+			}
+			if got.Retryable() != tt.retryable {
+				t.Fatalf("Retryable() = %v, want %v", got.Retryable(), tt.retryable)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		header map[string]string
+		want   time.Duration
+		ok     bool
+	}{
+		{header: map[string]string{"retry-after-ms": "250", "Retry-After": "10"}, want: 250 * time.Millisecond, ok: true},
+		{header: map[string]string{"x-ms-retry-after-ms": "40"}, want: 40 * time.Millisecond, ok: true},
+		{header: map[string]string{"Retry-After": "2"}, want: 2 * time.Second, ok: true},
+		{header: map[string]string{"Retry-After": time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)}, want: 0, ok: true},
+		{header: map[string]string{"Retry-After": "soon"}},
+		{header: map[string]string{}},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		for k, v := range tt.header {
+			h.Set(k, v)
+		}
+		got, ok := retryAfter(h)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%v) = %v, %v, want %v, %v", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
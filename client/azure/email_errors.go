@@ -0,0 +1,85 @@
+package azure
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// EmailError represents an error returned by the ACS email API
+type EmailError struct {
+	StatusCode int          `json:"-"`
+	Code       string       `json:"code"`
+	Message    string       `json:"message"`
+	Target     string       `json:"target,omitempty"`
+	Details    []EmailError `json:"details,omitempty"`
+	RequestID  string       `json:"-"`
+}
+
+func (e *EmailError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("email send failed: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	if e.Target != "" {
+		return fmt.Sprintf("email send failed: %d %s (%s): %s", e.StatusCode, e.Code, e.Target, e.Message)
+	}
+	return fmt.Sprintf("email send failed: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// Retryable reports whether the request may succeed if sent again
+func (e *EmailError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// parseEmailError builds an EmailError from an ACS error response.
+// ACS wraps the error in an {"error": {...}} envelope; bodies that do not
+// match are kept as the message so nothing is lost.
+func parseEmailError(resp *http.Response) *EmailError {
+	emailErr := &EmailError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("x-ms-request-id"),
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	var envelope struct {
+		Error *EmailError `json:"error"`
+	}
+	if err := json.Unmarshal(body, &envelope); err == nil && envelope.Error != nil {
+		emailErr.Code = envelope.Error.Code
+		emailErr.Message = envelope.Error.Message
+		emailErr.Target = envelope.Error.Target
+		emailErr.Details = envelope.Error.Details
+		return emailErr
+	}
+	emailErr.Message = string(body)
+	return emailErr
+}
+
+// retryAfter returns the server requested delay, checking the millisecond
+// headers ACS sends before falling back to the standard Retry-After header.
+func retryAfter(h http.Header) (time.Duration, bool) {
+	for _, key := range []string{"retry-after-ms", "x-ms-retry-after-ms"} {
+		if v := h.Get(key); v != "" {
+			if ms, err := strconv.Atoi(v); err == nil && ms >= 0 {
+				return time.Duration(ms) * time.Millisecond, true
+			}
+		}
+	}
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
@@ -0,0 +1,105 @@
+package azure
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// repeatabilityNamespace derives stable UUIDs from caller keys that are not UUIDs
+var repeatabilityNamespace = uuid.MustParse("6f1c6f3e-2a54-4d8e-9a0b-3c7d2f4e5a61")
+
+// repeatabilityRequestID returns key if it is a UUID, otherwise a UUID derived from it,
+// since ACS expects Repeatability-Request-ID to be a UUID
+func repeatabilityRequestID(key string) string {
+	if id, err := uuid.Parse(key); err == nil {
+		return id.String()
+	}
+	return uuid.NewSHA1(repeatabilityNamespace, []byte(key)).String()
+}
+
+// DefaultIdempotencyWindow is used when NewIdempotentEmailClient is given no window
+const DefaultIdempotencyWindow = 10 * time.Minute
+
+type idempotencyEntry struct {
+	firstSent time.Time
+	done      chan struct{} // closed when the in-flight attempt finishes
+	err       error
+}
+
+// IdempotentEmailClient deduplicates requests carrying the same
+// IdempotencyKey within Window. A key that was sent successfully is not
+// sent again; concurrent duplicates wait for the in-flight attempt; a
+// failed attempt may be retried and keeps its original first-sent time so
+// ACS can deduplicate on its side as well.
+type IdempotentEmailClient struct {
+	Next   ISendEmailClient
+	Window time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*idempotencyEntry
+}
+
+// NewIdempotentEmailClient creates an IdempotentEmailClient; a window of 0
+// means DefaultIdempotencyWindow
+func NewIdempotentEmailClient(next ISendEmailClient, window time.Duration) *IdempotentEmailClient {
+	if window <= 0 {
+		window = DefaultIdempotencyWindow
+	}
+	return &IdempotentEmailClient{Next: next, Window: window, entries: map[string]*idempotencyEntry{}}
+}
+
+func (c *IdempotentEmailClient) SendEmail(ctx context.Context, req SendEmailRequest) error {
+	if req.IdempotencyKey == "" {
+		return c.Next.SendEmail(ctx, req)
+	}
+	for {
+		c.mu.Lock()
+		c.evictExpired(time.Now())
+		entry, ok := c.entries[req.IdempotencyKey]
+		if !ok {
+			entry = &idempotencyEntry{firstSent: time.Now().UTC()}
+			c.entries[req.IdempotencyKey] = entry
+		}
+		done := entry.done
+		if done == nil {
+			// No attempt in flight: this caller sends, unless an earlier one succeeded
+			if ok && entry.err == nil {
+				c.mu.Unlock()
+				return nil
+			}
+			entry.done = make(chan struct{})
+			c.mu.Unlock()
+			return c.send(ctx, req, entry)
+		}
+		c.mu.Unlock()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (c *IdempotentEmailClient) send(ctx context.Context, req SendEmailRequest, entry *idempotencyEntry) error {
+	if req.FirstSent.IsZero() {
+		req.FirstSent = entry.firstSent
+	}
+	err := c.Next.SendEmail(ctx, req)
+	c.mu.Lock()
+	entry.err = err
+	close(entry.done)
+	entry.done = nil
+	c.mu.Unlock()
+	return err
+}
+
+func (c *IdempotentEmailClient) evictExpired(now time.Time) {
+	for key, entry := range c.entries {
+		if entry.done == nil && now.Sub(entry.firstSent) > c.Window {
+			delete(c.entries, key)
+		}
+	}
+}
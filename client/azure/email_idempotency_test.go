@@ -0,0 +1,147 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// scriptedEmailClient returns errs in order, then nil, and records the
+// requests it receives
+type scriptedEmailClient struct {
+	mu    sync.Mutex
+	errs  []error
+	sent  []SendEmailRequest
+	block chan struct{} // when set, every send waits for it to close
+}
+
+func (c *scriptedEmailClient) SendEmail(ctx context.Context, req SendEmailRequest) error {
+	if c.block != nil {
+		<-c.block
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sent = append(c.sent, req)
+	if len(c.errs) == 0 {
+		return nil
+	}
+	err := c.errs[0]
+	c.errs = c.errs[1:]
+	return err
+}
+
+func (c *scriptedEmailClient) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.sent)
+}
+
+func TestIdempotentEmailClientDedup(t *testing.T) {
+	sendErr := errors.New("service unavailable")
+	tests := []struct {
+		name     string
+		reqs     []SendEmailRequest
+		errs     []error
+		wantSent int
+		wantErrs []error
+	}{
+		{
+			name:     "duplicate after success is dropped",
+			reqs:     []SendEmailRequest{{IdempotencyKey: "k"}, {IdempotencyKey: "k"}},
+			wantSent: 1,
+			wantErrs: []error{nil, nil},
+		},
+		{
+			name:     "failed attempt is retried",
+			reqs:     []SendEmailRequest{{IdempotencyKey: "k"}, {IdempotencyKey: "k"}, {IdempotencyKey: "k"}},
+			errs:     []error{sendErr},
+			wantSent: 2,
+			wantErrs: []error{sendErr, nil, nil},
+		},
+		{
+			name:     "dry run is not remembered as sent",
+			reqs:     []SendEmailRequest{{IdempotencyKey: "k"}, {IdempotencyKey: "k"}},
+			errs:     []error{ErrDryRun},
+			wantSent: 2,
+			wantErrs: []error{ErrDryRun, nil},
+		},
+		{
+			name:     "different keys are both sent",
+			reqs:     []SendEmailRequest{{IdempotencyKey: "a"}, {IdempotencyKey: "b"}},
+			wantSent: 2,
+			wantErrs: []error{nil, nil},
+		},
+		{
+			name:     "requests without a key are always sent",
+			reqs:     []SendEmailRequest{{}, {}},
+			wantSent: 2,
+			wantErrs: []error{nil, nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &scriptedEmailClient{errs: tt.errs}
+			client := NewIdempotentEmailClient(next, time.Minute)
+			for i, req := range tt.reqs {
+				if err := client.SendEmail(context.Background(), req); !errors.Is(err, tt.wantErrs[i]) {
+					t.Fatalf("send %d: err = %v, want %v", i, err, tt.wantErrs[i])
+				}
+			}
+			if next.count() != tt.wantSent {
+				t.Fatalf("sent %d, want %d", next.count(), tt.wantSent)
+			}
+		})
+	}
+}
+
+func TestIdempotentEmailClientRetryKeepsFirstSent(t *testing.T) {
+	next := &scriptedEmailClient{errs: []error{errors.New("timeout")}}
+	client := NewIdempotentEmailClient(next, time.Minute)
+	client.SendEmail(context.Background(), SendEmailRequest{IdempotencyKey: "k"})
+	client.SendEmail(context.Background(), SendEmailRequest{IdempotencyKey: "k"})
+	if first, retry := next.sent[0].FirstSent, next.sent[1].FirstSent; first.IsZero() || !first.Equal(retry) {
+		t.Fatalf("FirstSent = %v then %v, want the same non-zero time", first, retry)
+	}
+}
+
+func TestIdempotentEmailClientConcurrentDuplicatesWait(t *testing.T) {
+	next := &scriptedEmailClient{block: make(chan struct{})}
+	client := NewIdempotentEmailClient(next, time.Minute)
+	var wg sync.WaitGroup
+	errs := make([]error, 5)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = client.SendEmail(context.Background(), SendEmailRequest{IdempotencyKey: "k"})
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(next.block)
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("send %d: %v", i, err)
+		}
+	}
+	if next.count() != 1 {
+		t.Fatalf("sent %d, want 1", next.count())
+	}
+}
+
+func TestIdempotentEmailClientWindow(t *testing.T) {
+	if got := NewIdempotentEmailClient(nil, 0).Window; got != DefaultIdempotencyWindow {
+		t.Fatalf("zero window = %v, want %v", got, DefaultIdempotencyWindow)
+	}
+
+	next := &scriptedEmailClient{}
+	client := NewIdempotentEmailClient(next, time.Millisecond)
+	client.SendEmail(context.Background(), SendEmailRequest{IdempotencyKey: "k"})
+	time.Sleep(5 * time.Millisecond)
+	client.SendEmail(context.Background(), SendEmailRequest{IdempotencyKey: "k"})
+	if next.count() != 2 {
+		t.Fatalf("sent %d after the window expired, want 2", next.count())
+	}
+}
@@ -0,0 +1,80 @@
+package azure
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+// EmailTemplate holds Go templates for the subject and bodies of a message.
+// HTML is rendered with html/template so per-recipient data is escaped.
+type EmailTemplate struct {
+	Name      string
+	Subject   string
+	PlainText string
+	HTML      string
+}
+
+// RenderedEmail is the result of rendering an EmailTemplate
+type RenderedEmail struct {
+	Subject   string `json:"subject"`
+	PlainText string `json:"plainText"`
+	HTML      string `json:"html,omitempty"`
+}
+
+// Render executes the template parts against data
+func (t EmailTemplate) Render(data any) (RenderedEmail, error) {
+	var out RenderedEmail
+	var err error
+	if out.Subject, err = renderText(t.Name+".subject", t.Subject, data); err != nil {
+		return out, err
+	}
+	out.Subject = strings.TrimSpace(out.Subject)
+	if out.PlainText, err = renderText(t.Name+".text", t.PlainText, data); err != nil {
+		return out, err
+	}
+	if t.HTML != "" {
+		tmpl, err := htmltemplate.New(t.Name + ".html").Option("missingkey=error").Parse(t.HTML)
+		if err != nil {
+			return out, fmt.Errorf("parse html template %s: %w", t.Name, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return out, fmt.Errorf("render html template %s: %w", t.Name, err)
+		}
+		out.HTML = buf.String()
+	}
+	return out, nil
+}
+
+// Request builds a SendEmailRequest for one recipient
+func (t EmailTemplate) Request(sender, recipient string, data any) (SendEmailRequest, error) {
+	rendered, err := t.Render(data)
+	if err != nil {
+		return SendEmailRequest{}, err
+	}
+	return SendEmailRequest{
+		Sender:    sender,
+		Recipient: recipient,
+		Subject:   rendered.Subject,
+		PlainText: rendered.PlainText,
+		HTML:      rendered.HTML,
+	}, nil
+}
+
+func renderText(name, text string, data any) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	tmpl, err := texttemplate.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parse template %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("render template %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,13 @@
+package azure
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// meter reports email metrics through the global MeterProvider; it is a
+// no-op until one is installed
+var meter = otel.Meter("azure-client-go/client/azure")
+
+var auditFailures, _ = meter.Int64Counter("email.audit.failures",
+	metric.WithDescription("Email audit entries that could not be recorded"))
@@ -0,0 +1,163 @@
+package azure
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// buildMIMEMessage renders a SendEmailRequest as an RFC 5322 message. The
+// body is multipart/alternative, wrapped in multipart/related when there are
+// inline images and in multipart/mixed when there are attachments.
+func buildMIMEMessage(req SendEmailRequest) ([]byte, error) {
+	from, to, err := parseAddresses(req)
+	if err != nil {
+		return nil, err
+	}
+	var inline, attached []EmailAttachment
+	for _, att := range req.Attachments {
+		if att.ContentID != "" {
+			inline = append(inline, att)
+		} else {
+			attached = append(attached, att)
+		}
+	}
+
+	contentType, body, err := alternativeBody(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(inline) > 0 {
+		if contentType, body, err = wrapBody("multipart/related", contentType, body, inline, "inline"); err != nil {
+			return nil, err
+		}
+	}
+	if len(attached) > 0 {
+		if contentType, body, err = wrapBody("multipart/mixed", contentType, body, attached, "attachment"); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", to)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", req.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", randomToken(), senderDomain(from.Address))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: %s\r\n\r\n", contentType)
+	buf.Write(body)
+	return buf.Bytes(), nil
+}
+
+// parseAddresses validates the sender and recipient, which also keeps
+// line breaks out of the From and To headers
+func parseAddresses(req SendEmailRequest) (from, to *mail.Address, err error) {
+	if from, err = mail.ParseAddress(req.Sender); err != nil {
+		return nil, nil, fmt.Errorf("invalid sender address %q: %w", req.Sender, err)
+	}
+	if to, err = mail.ParseAddress(req.Recipient); err != nil {
+		return nil, nil, fmt.Errorf("invalid recipient address %q: %w", req.Recipient, err)
+	}
+	return from, to, nil
+}
+
+// alternativeBody returns the plain text and, if set, HTML versions of the body
+func alternativeBody(req SendEmailRequest) (string, []byte, error) {
+	var body bytes.Buffer
+	alt := multipart.NewWriter(&body)
+	if err := writeTextPart(alt, "text/plain", req.PlainText); err != nil {
+		return "", nil, err
+	}
+	if req.HTML != "" {
+		if err := writeTextPart(alt, "text/html", req.HTML); err != nil {
+			return "", nil, err
+		}
+	}
+	if err := alt.Close(); err != nil {
+		return "", nil, err
+	}
+	return "multipart/alternative; boundary=" + alt.Boundary(), body.Bytes(), nil
+}
+
+// wrapBody returns a multipart body of kind holding the inner body followed
+// by the attachments
+func wrapBody(kind, innerType string, inner []byte, attachments []EmailAttachment, disposition string) (string, []byte, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreatePart(textproto.MIMEHeader{"Content-Type": {innerType}})
+	if err != nil {
+		return "", nil, err
+	}
+	if _, err := part.Write(inner); err != nil {
+		return "", nil, err
+	}
+	for _, att := range attachments {
+		if err := writeAttachmentPart(w, att, disposition); err != nil {
+			return "", nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return "", nil, err
+	}
+	return kind + "; boundary=" + w.Boundary(), body.Bytes(), nil
+}
+
+func writeTextPart(w *multipart.Writer, contentType, body string) error {
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType + "; charset=utf-8"},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return err
+	}
+	return writeBase64Lines(part, base64.StdEncoding.EncodeToString([]byte(body)))
+}
+
+func writeAttachmentPart(w *multipart.Writer, att EmailAttachment, disposition string) error {
+	header := textproto.MIMEHeader{
+		"Content-Type":              {att.ContentType},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {mime.FormatMediaType(disposition, map[string]string{"filename": att.Name})},
+	}
+	if att.ContentID != "" {
+		header.Set("Content-ID", "<"+att.ContentID+">")
+	}
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	return writeBase64Lines(part, att.ContentInBase64)
+}
+
+// writeBase64Lines wraps base64 content at 76 characters as required by RFC 2045
+func writeBase64Lines(w interface{ Write([]byte) (int, error) }, encoded string) error {
+	for len(encoded) > 76 {
+		if _, err := w.Write([]byte(encoded[:76] + "\r\n")); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err := w.Write([]byte(encoded + "\r\n"))
+	return err
+}
+
+func senderDomain(address string) string {
+	if i := strings.LastIndex(address, "@"); i >= 0 {
+		return strings.Trim(address[i+1:], "> ")
+	}
+	return "localhost"
+}
+
+func randomToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%x", b)
+}
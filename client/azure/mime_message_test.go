@@ -0,0 +1,189 @@
+package azure
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+// mimePart is a parsed message part; leaves hold their decoded content
+type mimePart struct {
+	mediaType   string
+	disposition string
+	contentID   string
+	content     string
+	parts       []mimePart
+}
+
+func parseMIMEPart(t *testing.T, contentType, encoding string, body io.Reader) mimePart {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := mimePart{mediaType: mediaType}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		r := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := r.NextRawPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			child := parseMIMEPart(t, part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			child.disposition, _, _ = mime.ParseMediaType(part.Header.Get("Content-Disposition"))
+			child.contentID = part.Header.Get("Content-ID")
+			p.parts = append(p.parts, child)
+		}
+		return p
+	}
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encoding == "base64" {
+		for _, line := range strings.Split(strings.TrimRight(string(raw), "\r\n"), "\r\n") {
+			if len(line) > 76 {
+				t.Errorf("base64 line of %d characters, want at most 76", len(line))
+			}
+		}
+		if raw, err = base64.StdEncoding.DecodeString(strings.ReplaceAll(string(raw), "\r\n", "")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p.content = string(raw)
+	return p
+}
+
+func parseMIMEMessage(t *testing.T, data []byte) (*mail.Message, mimePart) {
+	t.Helper()
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg, parseMIMEPart(t, msg.Header.Get("Content-Type"), "", msg.Body)
+}
+
+func mediaTypes(parts []mimePart) []string {
+	var types []string
+	for _, p := range parts {
+		types = append(types, p.mediaType)
+	}
+	return types
+}
+
+func TestBuildMIMEMessageHeaders(t *testing.T) {
+	data, err := buildMIMEMessage(SendEmailRequest{
+		Sender:    "Billing <billing@example.com>",
+		Recipient: "ada@example.com",
+		Subject:   "Grüße aus Zürich",
+		PlainText: "Hallo",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, _ := parseMIMEMessage(t, data)
+	if got := msg.Header.Get("Subject"); !strings.HasPrefix(got, "=?utf-8?q?") {
+		t.Errorf("Subject = %q, want a Q-encoded word", got)
+	}
+	var dec mime.WordDecoder
+	if subject, err := dec.DecodeHeader(msg.Header.Get("Subject")); err != nil || subject != "Grüße aus Zürich" {
+		t.Errorf("decoded Subject = %q, %v", subject, err)
+	}
+	if from, err := msg.Header.AddressList("From"); err != nil || from[0].Name != "Billing" || from[0].Address != "billing@example.com" {
+		t.Errorf("From = %v, %v", from, err)
+	}
+	if to := msg.Header.Get("To"); to != "<ada@example.com>" {
+		t.Errorf("To = %q", to)
+	}
+	if id := msg.Header.Get("Message-ID"); !strings.HasSuffix(id, "@example.com>") {
+		t.Errorf("Message-ID = %q, want the sender's domain", id)
+	}
+	if _, err := msg.Header.Date(); err != nil {
+		t.Errorf("Date: %v", err)
+	}
+	if msg.Header.Get("MIME-Version") != "1.0" {
+		t.Errorf("MIME-Version = %q", msg.Header.Get("MIME-Version"))
+	}
+}
+
+func TestBuildMIMEMessageStructure(t *testing.T) {
+	image := EmailAttachment{Name: "logo.png", ContentType: "image/png", ContentInBase64: base64.StdEncoding.EncodeToString([]byte("png")), ContentID: "logo"}
+	report, _ := NewAttachmentFromBytes("report.csv", bytes.Repeat([]byte("a,b,c\n"), 100))
+	req := SendEmailRequest{Sender: "noreply@example.com", Recipient: "ada@example.com", Subject: "Report", PlainText: "See attached", HTML: "<p>See attached <img src=\"cid:logo\"></p>"}
+
+	t.Run("text and HTML only", func(t *testing.T) {
+		data, err := buildMIMEMessage(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, root := parseMIMEMessage(t, data)
+		if root.mediaType != "multipart/alternative" || strings.Join(mediaTypes(root.parts), ",") != "text/plain,text/html" {
+			t.Fatalf("structure = %s %v, want multipart/alternative with text and HTML", root.mediaType, mediaTypes(root.parts))
+		}
+		if root.parts[0].content != req.PlainText || root.parts[1].content != req.HTML {
+			t.Fatalf("bodies = %q, %q", root.parts[0].content, root.parts[1].content)
+		}
+	})
+
+	t.Run("with inline image and attachment", func(t *testing.T) {
+		req := req
+		req.Attachments = []EmailAttachment{report, image}
+		data, err := buildMIMEMessage(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, root := parseMIMEMessage(t, data)
+		if root.mediaType != "multipart/mixed" || strings.Join(mediaTypes(root.parts), ",") != "multipart/related,text/csv" {
+			t.Fatalf("structure = %s %v, want multipart/mixed with related body and CSV", root.mediaType, mediaTypes(root.parts))
+		}
+		related := root.parts[0]
+		if strings.Join(mediaTypes(related.parts), ",") != "multipart/alternative,image/png" {
+			t.Fatalf("related parts = %v", mediaTypes(related.parts))
+		}
+		if img := related.parts[1]; img.disposition != "inline" || img.contentID != "<logo>" || img.content != "png" {
+			t.Fatalf("inline image = %+v", img)
+		}
+		// The CSV encodes to well over 76 characters, so it is wrapped
+		if csv := root.parts[1]; csv.disposition != "attachment" || csv.content != strings.Repeat("a,b,c\n", 100) {
+			t.Fatalf("attachment = %+v", csv)
+		}
+	})
+}
+
+func TestBuildMIMEMessageRejectsInvalidAddresses(t *testing.T) {
+	tests := []struct {
+		name, sender, recipient string
+	}{
+		{"missing sender", "", "ada@example.com"},
+		{"malformed recipient", "noreply@example.com", "ada at example.com"},
+		{"header injection in recipient", "noreply@example.com", "ada@example.com\r\nBcc: eve@example.com"},
+		{"header injection in sender", "noreply@example.com\nBcc: eve@example.com", "ada@example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := buildMIMEMessage(SendEmailRequest{Sender: tt.sender, Recipient: tt.recipient, Subject: "Hi"})
+			if err == nil || !strings.Contains(err.Error(), "invalid") {
+				t.Fatalf("error = %v, want invalid address", err)
+			}
+		})
+	}
+}
+
+func TestBuildMIMEMessageEncodesSubjectLineBreaks(t *testing.T) {
+	data, err := buildMIMEMessage(SendEmailRequest{Sender: "noreply@example.com", Recipient: "ada@example.com", Subject: "Hi\r\nBcc: eve@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, _ := parseMIMEMessage(t, data)
+	if msg.Header.Get("Bcc") != "" {
+		t.Fatal("subject injected a Bcc header")
+	}
+}
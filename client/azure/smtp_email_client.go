@@ -0,0 +1,158 @@
+package azure
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPEmailClient sends email through an SMTP relay
+type SMTPEmailClient struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	AuthType string // "plain" (default), "login" or "none"
+	StartTLS bool   // upgrade the connection with STARTTLS, required before auth on non-local hosts
+	// Timeout bounds the dial and the whole SMTP conversation when the
+	// context has no earlier deadline
+	Timeout time.Duration
+}
+
+func NewSMTPEmailClient(host string, port int, username, password, authType string, startTLS bool) *SMTPEmailClient {
+	return &SMTPEmailClient{
+		Host:     host,
+		Port:     port,
+		Username: username,
+		Password: password,
+		AuthType: authType,
+		StartTLS: startTLS,
+		Timeout:  30 * time.Second,
+	}
+}
+
+// SendEmail sends an email as a MIME multipart message
+func (c *SMTPEmailClient) SendEmail(ctx context.Context, req SendEmailRequest) error {
+	attachments := append([]EmailAttachment(nil), req.Attachments...)
+	if err := validateAttachments(attachments); err != nil {
+		return err
+	}
+	req.Attachments = attachments
+	msg, err := buildMIMEMessage(req)
+	if err != nil {
+		return err
+	}
+
+	from, to, err := parseAddresses(req)
+	if err != nil {
+		return err
+	}
+
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	addr := net.JoinHostPort(c.Host, fmt.Sprint(c.Port))
+	d := net.Dialer{Deadline: deadline}
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	_ = conn.SetDeadline(deadline)
+	// Cancelling ctx aborts the conversation
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	client, err := smtp.NewClient(conn, c.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if c.StartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("smtp server does not support STARTTLS")
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: c.Host}); err != nil {
+			return err
+		}
+	}
+	if auth := c.auth(); auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("smtp auth failed: %w", err)
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func (c *SMTPEmailClient) auth() smtp.Auth {
+	if c.Username == "" {
+		return nil
+	}
+	switch strings.ToLower(c.AuthType) {
+	case "none":
+		return nil
+	case "login":
+		return &loginAuth{username: c.Username, password: c.Password, host: c.Host}
+	default:
+		return smtp.PlainAuth("", c.Username, c.Password, c.Host)
+	}
+}
+
+// loginAuth implements the LOGIN mechanism, which net/smtp does not provide
+type loginAuth struct {
+	username, password, host string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(string(fromServer))) {
+	case "username:":
+		return []byte(a.username), nil
+	case "password:":
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected LOGIN challenge: %q", fromServer)
+	}
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
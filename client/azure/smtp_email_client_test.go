@@ -0,0 +1,150 @@
+package azure
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpServer accepts one connection and speaks just enough SMTP to take a
+// message, which it sends on received
+type smtpServer struct {
+	ln       net.Listener
+	received chan smtpMessage
+}
+
+type smtpMessage struct {
+	from, to string
+	data     string
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	s := &smtpServer{ln: ln, received: make(chan smtpMessage, 1)}
+	go s.serve()
+	return s
+}
+
+func (s *smtpServer) serve() {
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 localhost ESMTP")
+	var msg smtpMessage
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		switch upper := strings.ToUpper(cmd); {
+		case strings.HasPrefix(upper, "EHLO"), strings.HasPrefix(upper, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(upper, "MAIL FROM:"):
+			msg.from = cmd[len("MAIL FROM:"):]
+			reply("250 OK")
+		case strings.HasPrefix(upper, "RCPT TO:"):
+			msg.to = cmd[len("RCPT TO:"):]
+			reply("250 OK")
+		case upper == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			msg.data = data.String()
+			reply("250 OK")
+		case upper == "QUIT":
+			reply("221 Bye")
+			s.received <- msg
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func (s *smtpServer) client() *SMTPEmailClient {
+	host, port, _ := net.SplitHostPort(s.ln.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return NewSMTPEmailClient(host, p, "", "", "none", false)
+}
+
+func TestSMTPEmailClientSends(t *testing.T) {
+	srv := newSMTPServer(t)
+	err := srv.client().SendEmail(context.Background(), SendEmailRequest{
+		Sender:    "Billing <billing@example.com>",
+		Recipient: "ada@example.com",
+		Subject:   "Invoice",
+		PlainText: "Your invoice",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := <-srv.received
+	if msg.from != "<billing@example.com>" || msg.to != "<ada@example.com>" {
+		t.Fatalf("envelope = %s -> %s, want bare addresses", msg.from, msg.to)
+	}
+	if !strings.Contains(msg.data, "Subject: Invoice\r\n") {
+		t.Fatalf("message does not carry the subject:\n%s", msg.data)
+	}
+}
+
+func TestSMTPEmailClientTimesOutOnSilentServer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	// Accept connections but never greet
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	p, _ := strconv.Atoi(port)
+	client := NewSMTPEmailClient(host, p, "", "", "none", false)
+	client.Timeout = 100 * time.Millisecond
+
+	start := time.Now()
+	err = client.SendEmail(context.Background(), SendEmailRequest{Sender: "noreply@example.com", Recipient: "ada@example.com", Subject: "Hi"})
+	if err == nil {
+		t.Fatal("SendEmail() = nil, want a timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("SendEmail returned after %v, want about the 100ms timeout", elapsed)
+	}
+}
+
+func TestSMTPEmailClientRejectsInvalidAddresses(t *testing.T) {
+	client := NewSMTPEmailClient("127.0.0.1", 1, "", "", "none", false)
+	err := client.SendEmail(context.Background(), SendEmailRequest{Sender: "noreply@example.com", Recipient: "ada@example.com>\r\nRCPT TO:<eve@example.com", Subject: "Hi"})
+	if err == nil || !strings.Contains(err.Error(), "invalid recipient address") {
+		t.Fatalf("error = %v, want invalid recipient address", err)
+	}
+}
@@ -0,0 +1,37 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrRecipientSuppressed is returned when the recipient is on the suppression list
+var ErrRecipientSuppressed = errors.New("recipient is on the suppression list")
+
+// SuppressionChecker reports whether an address must not receive email
+type SuppressionChecker interface {
+	IsSuppressed(ctx context.Context, email string) (bool, error)
+}
+
+// SuppressingEmailClient consults a suppression list before delegating to
+// the wrapped client
+type SuppressingEmailClient struct {
+	Next       ISendEmailClient
+	Suppressed SuppressionChecker
+}
+
+func NewSuppressingEmailClient(next ISendEmailClient, checker SuppressionChecker) *SuppressingEmailClient {
+	return &SuppressingEmailClient{Next: next, Suppressed: checker}
+}
+
+func (c *SuppressingEmailClient) SendEmail(ctx context.Context, req SendEmailRequest) error {
+	suppressed, err := c.Suppressed.IsSuppressed(ctx, req.Recipient)
+	if err != nil {
+		return fmt.Errorf("suppression check failed: %w", err)
+	}
+	if suppressed {
+		return fmt.Errorf("%w: %s", ErrRecipientSuppressed, req.Recipient)
+	}
+	return c.Next.SendEmail(ctx, req)
+}
@@ -0,0 +1,318 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+// defaultExpiryDelta is how long before expiry a cached token is refreshed
+const defaultExpiryDelta = time.Minute
+
+// Authenticator adds credentials to an outgoing request
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// invalidator is implemented by authenticators whose credentials can go
+// stale before they expire, e.g. a revoked token
+type invalidator interface {
+	Invalidate()
+}
+
+// APIKeyAuth sends a static key in a request header
+type APIKeyAuth struct {
+	Header string // defaults to X-Api-Key
+	Key    string
+}
+
+func NewAPIKeyAuth(header, key string) *APIKeyAuth {
+	if header == "" {
+		header = "X-Api-Key"
+	}
+	return &APIKeyAuth{Header: header, Key: key}
+}
+
+func (a *APIKeyAuth) Authenticate(req *http.Request) error {
+	req.Header.Set(a.Header, a.Key)
+	return nil
+}
+
+// Token is an access token and when it stops being valid
+type Token struct {
+	AccessToken string
+	TokenType   string    // defaults to Bearer
+	ExpiresAt   time.Time // zero for tokens that do not expire
+}
+
+// valid reports whether the token can still be used delta before expiry
+func (t *Token) valid(now time.Time, delta time.Duration) bool {
+	return t != nil && t.AccessToken != "" && (t.ExpiresAt.IsZero() || now.Add(delta).Before(t.ExpiresAt))
+}
+
+// TokenSource supplies access tokens
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// StaticToken is a TokenSource that always returns the same token
+type StaticToken string
+
+func (s StaticToken) Token(context.Context) (*Token, error) {
+	return &Token{AccessToken: string(s)}, nil
+}
+
+// BearerAuth sends tokens from Source in the Authorization header
+type BearerAuth struct {
+	Source TokenSource
+}
+
+func NewBearerAuth(source TokenSource) *BearerAuth {
+	return &BearerAuth{Source: source}
+}
+
+func (a *BearerAuth) Authenticate(req *http.Request) error {
+	tok, err := a.Source.Token(req.Context())
+	if err != nil {
+		return fmt.Errorf("failed to get access token: %w", err)
+	}
+	tokenType := tok.TokenType
+	if tokenType == "" || strings.EqualFold(tokenType, "bearer") {
+		tokenType = "Bearer"
+	}
+	req.Header.Set("Authorization", tokenType+" "+tok.AccessToken)
+	return nil
+}
+
+func (a *BearerAuth) Invalidate() {
+	if inv, ok := a.Source.(invalidator); ok {
+		inv.Invalidate()
+	}
+}
+
+// ClientCredentialsConfig configures the OAuth2 client credentials grant
+type ClientCredentialsConfig struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// ExtraParams are added to the token request, e.g. audience or resource
+	ExtraParams url.Values
+	// ExpiryDelta refreshes tokens this long before they expire
+	ExpiryDelta time.Duration
+	// HTTPClient calls the token endpoint; it must not use the authenticator
+	// being configured. Defaults to a client with a 10s timeout.
+	HTTPClient *http.Client
+}
+
+// ClientCredentials is a TokenSource for the OAuth2 client credentials grant.
+// Tokens are cached and refreshed shortly before they expire; concurrent
+// callers share a single token request.
+type ClientCredentials struct {
+	cfg ClientCredentialsConfig
+
+	mu    sync.Mutex
+	token *Token
+}
+
+func NewClientCredentials(cfg ClientCredentialsConfig) *ClientCredentials {
+	if cfg.ExpiryDelta <= 0 {
+		cfg.ExpiryDelta = defaultExpiryDelta
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &ClientCredentials{cfg: cfg}
+}
+
+// NewOAuth2Auth returns a BearerAuth backed by the client credentials grant
+func NewOAuth2Auth(cfg ClientCredentialsConfig) *BearerAuth {
+	return NewBearerAuth(NewClientCredentials(cfg))
+}
+
+func (c *ClientCredentials) Token(ctx context.Context) (*Token, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token.valid(time.Now(), c.cfg.ExpiryDelta) {
+		return c.token, nil
+	}
+	tok, err := c.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.token = tok
+	return tok, nil
+}
+
+// Invalidate drops the cached token so the next call fetches a new one
+func (c *ClientCredentials) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = nil
+}
+
+func (c *ClientCredentials) fetch(ctx context.Context) (*Token, error) {
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {c.cfg.ClientID},
+		"client_secret": {c.cfg.ClientSecret},
+	}
+	if len(c.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.cfg.Scopes, " "))
+	}
+	for k, v := range c.cfg.ExtraParams {
+		form[k] = v
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	start := time.Now()
+	resp, err := c.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		oauthErr := &OAuthError{StatusCode: resp.StatusCode}
+		if json.Unmarshal(body, oauthErr) != nil || oauthErr.Code == "" {
+			oauthErr.Description = strings.TrimSpace(string(body))
+		}
+		return nil, oauthErr
+	}
+
+	var tr struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if tr.AccessToken == "" {
+		return nil, errors.New("token response has no access_token")
+	}
+	tok := &Token{AccessToken: tr.AccessToken, TokenType: tr.TokenType}
+	if tr.ExpiresIn > 0 {
+		// Measure from when the request was sent so slow responses err on the early side
+		tok.ExpiresAt = start.Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+	return tok, nil
+}
+
+// OAuthError is an error response from a token endpoint (RFC 6749 section 5.2)
+type OAuthError struct {
+	StatusCode  int    `json:"-"`
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *OAuthError) Error() string {
+	msg := fmt.Sprintf("token endpoint returned %d", e.StatusCode)
+	if e.Code != "" {
+		msg += ": " + e.Code
+	}
+	if e.Description != "" {
+		msg += ": " + e.Description
+	}
+	return msg
+}
+
+// AzureADTokenSource gets tokens for Scopes from an azcore credential, which
+// caches and refreshes them itself
+type AzureADTokenSource struct {
+	Credential azcore.TokenCredential
+	Scopes     []string
+}
+
+func (s *AzureADTokenSource) Token(ctx context.Context) (*Token, error) {
+	tok, err := s.Credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: s.Scopes})
+	if err != nil {
+		return nil, err
+	}
+	return &Token{AccessToken: tok.Token, ExpiresAt: tok.ExpiresOn}, nil
+}
+
+// NewAzureADAuth authenticates with DefaultAzureCredential (environment,
+// workload identity, managed identity or the Azure CLI), e.g. for the
+// scope "api://<app-id>/.default"
+func NewAzureADAuth(scopes ...string) (*BearerAuth, error) {
+	cred, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Azure credential: %w", err)
+	}
+	return NewBearerAuth(&AzureADTokenSource{Credential: cred, Scopes: scopes}), nil
+}
+
+// AuthRoundTripper authenticates each request. A 401 response invalidates
+// cached credentials and the request is sent once more with fresh ones,
+// provided its body can be rewound.
+type AuthRoundTripper struct {
+	Proxied http.RoundTripper
+	Auth    Authenticator
+}
+
+// WithAuth returns a Middleware applying auth
+func WithAuth(auth Authenticator) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &AuthRoundTripper{Proxied: next, Auth: auth}
+	}
+}
+
+func (art *AuthRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	authed := req.Clone(req.Context())
+	if err := art.Auth.Authenticate(authed); err != nil {
+		return nil, err
+	}
+	resp, err := art.Proxied.RoundTrip(authed)
+	inv, ok := art.Auth.(invalidator)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || !ok {
+		return resp, err
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, err
+	}
+
+	inv.Invalidate()
+	retry := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		retry.Body = body
+	}
+	if err := art.Auth.Authenticate(retry); err != nil {
+		return resp, nil
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+	return art.Proxied.RoundTrip(retry)
+}
+
+// WithAuth installs auth beneath logging, so every attempt including retries
+// carries current credentials and they never reach the request log
+func (c *HTTPClient) WithAuth(auth Authenticator) *HTTPClient {
+	if auth != nil {
+		c.Logging.Proxied = WithAuth(auth)(c.Logging.Proxied)
+	}
+	return c
+}
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TokenEndpointStub is a local OAuth2 token endpoint for exercising
+// ClientCredentials without a real identity provider. It issues random
+// tokens for one client and can check them on protected handlers.
+type TokenEndpointStub struct {
+	ClientID     string
+	ClientSecret string
+	TTL          time.Duration
+
+	mu     sync.Mutex
+	issued map[string]time.Time // token -> expiry
+	count  int
+}
+
+func NewTokenEndpointStub(clientID, clientSecret string, ttl time.Duration) *TokenEndpointStub {
+	return &TokenEndpointStub{ClientID: clientID, ClientSecret: clientSecret, TTL: ttl, issued: map[string]time.Time{}}
+}
+
+// ServeHTTP implements the client credentials grant
+func (s *TokenEndpointStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != http.MethodPost || r.ParseForm() != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(OAuthError{Code: "invalid_request"})
+		return
+	}
+	if r.PostForm.Get("grant_type") != "client_credentials" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(OAuthError{Code: "unsupported_grant_type"})
+		return
+	}
+	id, secret, ok := r.BasicAuth()
+	if !ok {
+		id, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if id != s.ClientID || secret != s.ClientSecret {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(OAuthError{Code: "invalid_client"})
+		return
+	}
+
+	b := make([]byte, 16)
+	rand.Read(b)
+	token := hex.EncodeToString(b)
+	s.mu.Lock()
+	s.issued[token] = time.Now().Add(s.TTL)
+	s.count++
+	s.mu.Unlock()
+	json.NewEncoder(w).Encode(map[string]any{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   int64(s.TTL / time.Second),
+	})
+}
+
+// Issued returns how many tokens have been issued
+func (s *TokenEndpointStub) Issued() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.count
+}
+
+// Revoke invalidates every issued token
+func (s *TokenEndpointStub) Revoke() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.issued = map[string]time.Time{}
+}
+
+// Protect answers 401 unless the request carries a live token from the stub
+func (s *TokenEndpointStub) Protect(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := bearerToken(r)
+		s.mu.Lock()
+		expiry, known := s.issued[token]
+		s.mu.Unlock()
+		if !ok || !known || time.Now().After(expiry) {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	const prefix = "Bearer "
+	h := r.Header.Get("Authorization")
+	if len(h) <= len(prefix) || h[:len(prefix)] != prefix {
+		return "", false
+	}
+	return h[len(prefix):], true
+}
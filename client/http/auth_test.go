@@ -0,0 +1,187 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// authServer serves the stub's token endpoint at /token and echoes the
+// request body on every other path, behind protect
+func authServer(t *testing.T, stub *TokenEndpointStub, protect func(http.Handler) http.Handler) *httptest.Server {
+	t.Helper()
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	})
+	mux := http.NewServeMux()
+	mux.Handle("/token", stub)
+	mux.Handle("/", protect(echo))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func requireHeader(name, value string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get(name) != value {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestAuthRoundTripper(t *testing.T) {
+	stub := NewTokenEndpointStub("client", "secret", time.Hour)
+	tests := []struct {
+		name    string
+		protect func(http.Handler) http.Handler
+		auth    func(tokenURL string) Authenticator
+		want    int
+	}{
+		{
+			name:    "api key in the default header",
+			protect: requireHeader("X-Api-Key", "k1"),
+			auth:    func(string) Authenticator { return NewAPIKeyAuth("", "k1") },
+			want:    http.StatusOK,
+		},
+		{
+			name:    "api key in a custom header",
+			protect: requireHeader("Api-Key", "k1"),
+			auth:    func(string) Authenticator { return NewAPIKeyAuth("Api-Key", "k1") },
+			want:    http.StatusOK,
+		},
+		{
+			name:    "wrong api key",
+			protect: requireHeader("X-Api-Key", "k1"),
+			auth:    func(string) Authenticator { return NewAPIKeyAuth("", "k2") },
+			want:    http.StatusUnauthorized,
+		},
+		{
+			name:    "static bearer token",
+			protect: requireHeader("Authorization", "Bearer t1"),
+			auth:    func(string) Authenticator { return NewBearerAuth(StaticToken("t1")) },
+			want:    http.StatusOK,
+		},
+		{
+			name:    "oauth2 client credentials",
+			protect: stub.Protect,
+			auth: func(tokenURL string) Authenticator {
+				return NewOAuth2Auth(ClientCredentialsConfig{TokenURL: tokenURL, ClientID: "client", ClientSecret: "secret"})
+			},
+			want: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := authServer(t, stub, tt.protect)
+			client := &http.Client{Transport: WithAuth(tt.auth(srv.URL + "/token"))(http.DefaultTransport)}
+			resp, err := client.Get(srv.URL + "/members")
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientCredentialsWrongSecret(t *testing.T) {
+	stub := NewTokenEndpointStub("client", "secret", time.Hour)
+	srv := authServer(t, stub, stub.Protect)
+	source := NewClientCredentials(ClientCredentialsConfig{TokenURL: srv.URL + "/token", ClientID: "client", ClientSecret: "wrong"})
+	_, err := source.Token(context.Background())
+	var oauthErr *OAuthError
+	if !errors.As(err, &oauthErr) || oauthErr.StatusCode != http.StatusUnauthorized || oauthErr.Code != "invalid_client" {
+		t.Fatalf("err = %v, want invalid_client", err)
+	}
+}
+
+func TestClientCredentialsRefresh(t *testing.T) {
+	tests := []struct {
+		name       string
+		ttl        time.Duration
+		delta      time.Duration
+		wantIssued int
+	}{
+		{name: "cached while outside the expiry skew", ttl: time.Hour, delta: time.Minute, wantIssued: 1},
+		{name: "refreshed inside the expiry skew", ttl: time.Minute, delta: 2 * time.Minute, wantIssued: 3},
+		{name: "default skew of a minute", ttl: 30 * time.Second, wantIssued: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := NewTokenEndpointStub("client", "secret", tt.ttl)
+			srv := authServer(t, stub, stub.Protect)
+			source := NewClientCredentials(ClientCredentialsConfig{
+				TokenURL: srv.URL + "/token", ClientID: "client", ClientSecret: "secret", ExpiryDelta: tt.delta,
+			})
+			for i := 0; i < 3; i++ {
+				if _, err := source.Token(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if stub.Issued() != tt.wantIssued {
+				t.Fatalf("issued %d tokens, want %d", stub.Issued(), tt.wantIssued)
+			}
+		})
+	}
+}
+
+func TestAuthRoundTripperReauthenticatesOn401(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       func() io.Reader
+		wantStatus int
+		wantIssued int
+	}{
+		{name: "GET", wantStatus: http.StatusOK, wantIssued: 2},
+		{name: "rewindable body", body: func() io.Reader { return strings.NewReader("payload") }, wantStatus: http.StatusOK, wantIssued: 2},
+		{name: "body that cannot be rewound", body: func() io.Reader { return io.MultiReader(strings.NewReader("payload")) }, wantStatus: http.StatusUnauthorized, wantIssued: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := NewTokenEndpointStub("client", "secret", time.Hour)
+			srv := authServer(t, stub, stub.Protect)
+			auth := NewOAuth2Auth(ClientCredentialsConfig{TokenURL: srv.URL + "/token", ClientID: "client", ClientSecret: "secret"})
+			client := &http.Client{Transport: WithAuth(auth)(http.DefaultTransport)}
+
+			resp, err := client.Get(srv.URL + "/warmup")
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			// The cached token is still unexpired but the server no longer accepts it
+			stub.Revoke()
+
+			method, body := http.MethodGet, io.Reader(nil)
+			if tt.body != nil {
+				method, body = http.MethodPost, tt.body()
+			}
+			req, _ := http.NewRequest(method, srv.URL+"/members", body)
+			resp, err = client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK && tt.body != nil && string(got) != "payload" {
+				t.Errorf("retried body = %q, want payload", got)
+			}
+			if stub.Issued() != tt.wantIssued {
+				t.Errorf("issued %d tokens, want %d", stub.Issued(), tt.wantIssued)
+			}
+		})
+	}
+}
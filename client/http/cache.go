@@ -0,0 +1,346 @@
+package http
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// defaultMaxCachedBody is the largest response body CachingRoundTripper stores
+const defaultMaxCachedBody = 1 << 20
+
+// Cache lookup results, reported in the X-Cache header and metrics
+const (
+	CacheHit         = "hit"
+	CacheMiss        = "miss"
+	CacheRevalidated = "revalidated"
+	CacheBypass      = "bypass"
+)
+
+// CachedResponse is a stored response; it is JSON serializable so stores
+// can persist it
+type CachedResponse struct {
+	StatusCode int               `json:"statusCode"`
+	Header     http.Header       `json:"header"`
+	Body       []byte            `json:"body"`
+	StoredAt   time.Time         `json:"storedAt"`
+	Vary       map[string]string `json:"vary,omitempty"` // request header values named by Vary
+}
+
+// CacheStore persists cached responses
+type CacheStore interface {
+	// Get returns the response stored under key; ok is false on a miss
+	Get(ctx context.Context, key string) (resp *CachedResponse, ok bool, err error)
+	Set(ctx context.Context, key string, resp *CachedResponse) error
+	Delete(ctx context.Context, key string) error
+}
+
+// LRUCacheStore is an in-memory CacheStore evicting the least recently used
+// entries beyond MaxEntries
+type LRUCacheStore struct {
+	MaxEntries int
+
+	mu      sync.Mutex
+	order   *list.List // front is most recently used
+	entries map[string]*list.Element
+}
+
+type lruEntry struct {
+	key  string
+	resp *CachedResponse
+}
+
+func NewLRUCacheStore(maxEntries int) *LRUCacheStore {
+	if maxEntries < 1 {
+		maxEntries = 1000
+	}
+	return &LRUCacheStore{MaxEntries: maxEntries, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+func (s *LRUCacheStore) Get(_ context.Context, key string) (*CachedResponse, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	el, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	s.order.MoveToFront(el)
+	return el.Value.(*lruEntry).resp, true, nil
+}
+
+func (s *LRUCacheStore) Set(_ context.Context, key string, resp *CachedResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.entries[key]; ok {
+		el.Value.(*lruEntry).resp = resp
+		s.order.MoveToFront(el)
+		return nil
+	}
+	s.entries[key] = s.order.PushFront(&lruEntry{key: key, resp: resp})
+	for s.order.Len() > s.MaxEntries {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*lruEntry).key)
+	}
+	return nil
+}
+
+func (s *LRUCacheStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.entries[key]; ok {
+		s.order.Remove(el)
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+// Len returns the number of cached entries
+func (s *LRUCacheStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+// CachingRoundTripper is a private HTTP cache (RFC 9111) for GET requests.
+// Fresh responses, per Cache-Control max-age or Expires, are served from
+// Store; stale ones are revalidated with If-None-Match/If-Modified-Since and
+// a 304 refreshes the stored copy. Successful unsafe requests invalidate the
+// cached entry for their URL. Store errors never fail a request.
+type CachingRoundTripper struct {
+	Proxied      http.RoundTripper
+	Store        CacheStore
+	MaxBodyBytes int64 // larger responses are not cached
+}
+
+// WithCache returns a Middleware caching responses in store
+func WithCache(store CacheStore) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &CachingRoundTripper{Proxied: next, Store: store, MaxBodyBytes: defaultMaxCachedBody}
+	}
+}
+
+// WithCache installs a response cache as the outermost middleware, so hits
+// skip the circuit breaker, retries and rate limits
+func (c *HTTPClient) WithCache(store CacheStore) *HTTPClient {
+	return c.Use(WithCache(store))
+}
+
+func (crt *CachingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	key := req.URL.String()
+	if req.Method != http.MethodGet {
+		resp, err := crt.Proxied.RoundTrip(req)
+		if err == nil && !isSafeMethod(req.Method) && resp.StatusCode < 400 {
+			crt.Store.Delete(ctx, key)
+		}
+		return resp, err
+	}
+	reqCC := parseCacheControl(req.Header.Get("Cache-Control"))
+	if _, ok := reqCC["no-store"]; ok || req.Header.Get("Range") != "" {
+		crt.record(ctx, req, CacheBypass)
+		return crt.Proxied.RoundTrip(req)
+	}
+
+	cached, ok, _ := crt.Store.Get(ctx, key)
+	if ok && !cached.matches(req) {
+		cached, ok = nil, false
+	}
+	if ok {
+		_, noCache := reqCC["no-cache"]
+		if !noCache && cached.fresh(time.Now()) {
+			crt.record(ctx, req, CacheHit)
+			return cached.response(req, CacheHit), nil
+		}
+		if etag, lastMod := cached.Header.Get("ETag"), cached.Header.Get("Last-Modified"); etag != "" || lastMod != "" {
+			req = req.Clone(ctx)
+			if etag != "" {
+				req.Header.Set("If-None-Match", etag)
+			}
+			if lastMod != "" {
+				req.Header.Set("If-Modified-Since", lastMod)
+			}
+		}
+	}
+
+	resp, err := crt.Proxied.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if ok && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		cached = cached.refreshed(resp.Header)
+		crt.Store.Set(ctx, key, cached)
+		crt.record(ctx, req, CacheRevalidated)
+		return cached.response(req, CacheRevalidated), nil
+	}
+	crt.record(ctx, req, CacheMiss)
+	return crt.store(req, key, resp), nil
+}
+
+// store saves resp when it is cacheable, returning a response whose body is
+// still unread by the caller
+func (crt *CachingRoundTripper) store(req *http.Request, key string, resp *http.Response) *http.Response {
+	ctx := req.Context()
+	if !cacheable(resp) {
+		crt.Store.Delete(ctx, key)
+		return resp
+	}
+	limit := crt.MaxBodyBytes
+	if limit <= 0 {
+		limit = defaultMaxCachedBody
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil || int64(len(body)) > limit {
+		// Too big or broken: hand back what was read followed by the rest
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	entry := &CachedResponse{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+		Body:       body,
+		StoredAt:   time.Now(),
+	}
+	for _, name := range varyHeaders(resp.Header) {
+		if entry.Vary == nil {
+			entry.Vary = map[string]string{}
+		}
+		entry.Vary[name] = req.Header.Get(name)
+	}
+	crt.Store.Set(ctx, key, entry)
+	resp.Header.Set("X-Cache", CacheMiss)
+	return resp
+}
+
+func (crt *CachingRoundTripper) record(ctx context.Context, req *http.Request, result string) {
+	cacheRequests.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("server.address", req.URL.Host),
+		attribute.String("result", result),
+	))
+}
+
+// cacheable reports whether a GET response may be stored and later reused
+func cacheable(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusOK {
+		return false
+	}
+	cc := parseCacheControl(resp.Header.Get("Cache-Control"))
+	if _, ok := cc["no-store"]; ok {
+		return false
+	}
+	for _, name := range varyHeaders(resp.Header) {
+		if name == "*" {
+			return false
+		}
+	}
+	// Worth storing only if it can be served fresh or revalidated
+	_, hasMaxAge := cc["max-age"]
+	return hasMaxAge || resp.Header.Get("Expires") != "" ||
+		resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""
+}
+
+// fresh reports whether the entry can be served without revalidation
+func (c *CachedResponse) fresh(now time.Time) bool {
+	cc := parseCacheControl(c.Header.Get("Cache-Control"))
+	if _, ok := cc["no-cache"]; ok {
+		return false
+	}
+	age := now.Sub(c.StoredAt)
+	if v, ok := cc["max-age"]; ok {
+		secs, err := strconv.Atoi(v)
+		return err == nil && age < time.Duration(secs)*time.Second
+	}
+	if exp := c.Header.Get("Expires"); exp != "" {
+		t, err := http.ParseTime(exp)
+		return err == nil && now.Before(t)
+	}
+	return false
+}
+
+// matches checks the request headers named by Vary against the stored ones
+func (c *CachedResponse) matches(req *http.Request) bool {
+	for name, value := range c.Vary {
+		if req.Header.Get(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// refreshed returns a copy updated with the headers of a 304 response
+func (c *CachedResponse) refreshed(h http.Header) *CachedResponse {
+	updated := *c
+	updated.Header = c.Header.Clone()
+	for _, name := range []string{"Cache-Control", "Date", "ETag", "Expires", "Last-Modified", "Vary"} {
+		if v := h.Values(name); len(v) > 0 {
+			updated.Header[name] = v
+		}
+	}
+	updated.StoredAt = time.Now()
+	return &updated
+}
+
+func (c *CachedResponse) response(req *http.Request, result string) *http.Response {
+	header := c.Header.Clone()
+	header.Set("X-Cache", result)
+	header.Set("Age", strconv.Itoa(int(time.Since(c.StoredAt).Seconds())))
+	return &http.Response{
+		Status:        strconv.Itoa(c.StatusCode) + " " + http.StatusText(c.StatusCode),
+		StatusCode:    c.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(c.Body)),
+		ContentLength: int64(len(c.Body)),
+		Request:       req,
+	}
+}
+
+func parseCacheControl(v string) map[string]string {
+	cc := map[string]string{}
+	for _, part := range strings.Split(v, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name == "" {
+			continue
+		}
+		cc[strings.ToLower(name)] = strings.Trim(value, `"`)
+	}
+	return cc
+}
+
+func varyHeaders(h http.Header) []string {
+	var names []string
+	for _, v := range h.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return names
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,198 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// cacheStep is one request through the cache and what it should see
+type cacheStep struct {
+	method    string
+	header    http.Header
+	wantCache string // X-Cache of the response, "" when not set
+	wantBody  string
+}
+
+func originResponse(req *http.Request, status int, header http.Header, body string) *http.Response {
+	resp := response(req, status, header)
+	resp.Body = io.NopCloser(strings.NewReader(body))
+	return resp
+}
+
+func TestCachingRoundTripper(t *testing.T) {
+	const lastModified = "Mon, 05 Jan 2026 10:00:00 GMT"
+	tests := []struct {
+		name string
+		// origin answers the nth (1-based) request that reaches it
+		origin    func(n int, req *http.Request) *http.Response
+		steps     []cacheStep
+		wantCalls int
+	}{
+		{
+			name: "fresh for max-age",
+			origin: func(n int, req *http.Request) *http.Response {
+				return originResponse(req, 200, http.Header{"Cache-Control": {"max-age=60"}}, fmt.Sprint("v", n))
+			},
+			steps: []cacheStep{
+				{wantCache: CacheMiss, wantBody: "v1"},
+				{wantCache: CacheHit, wantBody: "v1"},
+				{header: http.Header{"Cache-Control": {"no-cache"}}, wantCache: CacheMiss, wantBody: "v2"},
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "etag revalidation",
+			origin: func(n int, req *http.Request) *http.Response {
+				if req.Header.Get("If-None-Match") == `"v1"` {
+					return originResponse(req, http.StatusNotModified, http.Header{"Etag": {`"v1"`}}, "")
+				}
+				return originResponse(req, 200, http.Header{"Etag": {`"v1"`}, "Cache-Control": {"no-cache"}}, "v1")
+			},
+			steps: []cacheStep{
+				{wantCache: CacheMiss, wantBody: "v1"},
+				{wantCache: CacheRevalidated, wantBody: "v1"},
+				{wantCache: CacheRevalidated, wantBody: "v1"},
+			},
+			wantCalls: 3,
+		},
+		{
+			name: "last-modified revalidation",
+			origin: func(n int, req *http.Request) *http.Response {
+				if req.Header.Get("If-Modified-Since") == lastModified {
+					return originResponse(req, http.StatusNotModified, nil, "")
+				}
+				return originResponse(req, 200, http.Header{"Last-Modified": {lastModified}}, "v1")
+			},
+			steps: []cacheStep{
+				{wantCache: CacheMiss, wantBody: "v1"},
+				{wantCache: CacheRevalidated, wantBody: "v1"},
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "vary mismatch",
+			origin: func(n int, req *http.Request) *http.Response {
+				header := http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"accept-language"}}
+				return originResponse(req, 200, header, req.Header.Get("Accept-Language"))
+			},
+			steps: []cacheStep{
+				{header: http.Header{"Accept-Language": {"th"}}, wantCache: CacheMiss, wantBody: "th"},
+				{header: http.Header{"Accept-Language": {"en"}}, wantCache: CacheMiss, wantBody: "en"},
+				{header: http.Header{"Accept-Language": {"en"}}, wantCache: CacheHit, wantBody: "en"},
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "no-store response",
+			origin: func(n int, req *http.Request) *http.Response {
+				return originResponse(req, 200, http.Header{"Cache-Control": {"no-store, max-age=60"}}, fmt.Sprint("v", n))
+			},
+			steps:     []cacheStep{{wantBody: "v1"}, {wantBody: "v2"}},
+			wantCalls: 2,
+		},
+		{
+			name: "no-store request bypasses a cached entry",
+			origin: func(n int, req *http.Request) *http.Response {
+				return originResponse(req, 200, http.Header{"Cache-Control": {"max-age=60"}}, fmt.Sprint("v", n))
+			},
+			steps: []cacheStep{
+				{wantCache: CacheMiss, wantBody: "v1"},
+				{header: http.Header{"Cache-Control": {"no-store"}}, wantBody: "v2"},
+				{wantCache: CacheHit, wantBody: "v1"},
+			},
+			wantCalls: 2,
+		},
+		{
+			name: "unsafe method invalidates",
+			origin: func(n int, req *http.Request) *http.Response {
+				return originResponse(req, 200, http.Header{"Cache-Control": {"max-age=60"}}, fmt.Sprint("v", n))
+			},
+			steps: []cacheStep{
+				{wantCache: CacheMiss, wantBody: "v1"},
+				{wantCache: CacheHit, wantBody: "v1"},
+				{method: http.MethodPut, wantBody: "v2"},
+				{wantCache: CacheMiss, wantBody: "v3"},
+			},
+			wantCalls: 3,
+		},
+		{
+			name: "failed unsafe method keeps the entry",
+			origin: func(n int, req *http.Request) *http.Response {
+				if req.Method == http.MethodDelete {
+					return originResponse(req, http.StatusConflict, nil, "conflict")
+				}
+				return originResponse(req, 200, http.Header{"Cache-Control": {"max-age=60"}}, fmt.Sprint("v", n))
+			},
+			steps: []cacheStep{
+				{wantCache: CacheMiss, wantBody: "v1"},
+				{method: http.MethodDelete, wantBody: "conflict"},
+				{wantCache: CacheHit, wantBody: "v1"},
+			},
+			wantCalls: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			rt := WithCache(NewLRUCacheStore(10))(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				calls++
+				return tt.origin(calls, req), nil
+			}))
+			for i, step := range tt.steps {
+				method := step.method
+				if method == "" {
+					method = http.MethodGet
+				}
+				req, _ := http.NewRequestWithContext(context.Background(), method, "http://members.example.com/members/1", nil)
+				for k, v := range step.header {
+					req.Header[k] = v
+				}
+				resp, err := rt.RoundTrip(req)
+				if err != nil {
+					t.Fatalf("step %d: %v", i+1, err)
+				}
+				body, _ := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				if got := resp.Header.Get("X-Cache"); got != step.wantCache {
+					t.Errorf("step %d: X-Cache = %q, want %q", i+1, got, step.wantCache)
+				}
+				if string(body) != step.wantBody {
+					t.Errorf("step %d: body = %q, want %q", i+1, body, step.wantBody)
+				}
+				if resp.StatusCode != http.StatusOK && method == http.MethodGet {
+					t.Errorf("step %d: status = %d, want 200", i+1, resp.StatusCode)
+				}
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("origin calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestLRUCacheStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	s := NewLRUCacheStore(2)
+	for _, key := range []string{"a", "b"} {
+		s.Set(ctx, key, &CachedResponse{StatusCode: 200})
+	}
+	s.Get(ctx, "a") // b is now the least recently used
+	s.Set(ctx, "c", &CachedResponse{StatusCode: 200})
+
+	if s.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", s.Len())
+	}
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok, _ := s.Get(ctx, key); ok != want {
+			t.Errorf("Get(%q) ok = %v, want %v", key, ok, want)
+		}
+	}
+	s.Delete(ctx, "a")
+	if _, ok, _ := s.Get(ctx, "a"); ok || s.Len() != 1 {
+		t.Errorf("after Delete: ok = %v, Len() = %d", ok, s.Len())
+	}
+}
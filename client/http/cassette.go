@@ -0,0 +1,394 @@
+package http
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"unicode/utf8"
+)
+
+// ErrNoInteraction is returned in replay mode when no recorded interaction
+// matches a request
+var ErrNoInteraction = errors.New("no matching interaction in cassette")
+
+// CassetteMode selects what a Recorder does with requests
+type CassetteMode int
+
+const (
+	// ModeReplay serves recorded responses and never touches the network
+	ModeReplay CassetteMode = iota
+	// ModeRecord sends requests and appends each interaction to the cassette
+	ModeRecord
+	// ModeReplayOrRecord replays matches and records anything new
+	ModeReplayOrRecord
+)
+
+// ParseCassetteMode parses "replay", "record" or "replay_or_record"
+func ParseCassetteMode(s string) (CassetteMode, error) {
+	switch s {
+	case "replay", "":
+		return ModeReplay, nil
+	case "record":
+		return ModeRecord, nil
+	case "replay_or_record":
+		return ModeReplayOrRecord, nil
+	}
+	return 0, fmt.Errorf("unknown cassette mode %q", s)
+}
+
+// RecordedRequest is the request half of an interaction
+type RecordedRequest struct {
+	Method       string      `json:"method"`
+	URL          string      `json:"url"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"bodyEncoding,omitempty"` // "base64" for binary bodies
+}
+
+// RecordedResponse is the response half of an interaction
+type RecordedResponse struct {
+	StatusCode   int         `json:"statusCode"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"bodyEncoding,omitempty"`
+}
+
+// Interaction is one recorded request and its response
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// Cassette is a JSON file of interactions
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// LoadCassette reads a cassette; a missing file yields an empty cassette
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Cassette{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("invalid cassette %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// Save writes the cassette to path, replacing it atomically
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Matcher reports whether a live request matches a recorded one; body is
+// the live request body
+type Matcher func(req *http.Request, body []byte, rec RecordedRequest) bool
+
+// MatchMethod compares request methods
+func MatchMethod(req *http.Request, _ []byte, rec RecordedRequest) bool {
+	return req.Method == rec.Method
+}
+
+// MatchPath compares scheme, host and path
+func MatchPath(req *http.Request, _ []byte, rec RecordedRequest) bool {
+	u, err := url.Parse(rec.URL)
+	return err == nil && u.Scheme == req.URL.Scheme && u.Host == req.URL.Host && u.Path == req.URL.Path
+}
+
+// MatchQuery compares query parameters in any order; redacted values in the
+// recording match any value
+func MatchQuery(req *http.Request, _ []byte, rec RecordedRequest) bool {
+	u, err := url.Parse(rec.URL)
+	if err != nil {
+		return false
+	}
+	want, got := u.Query(), req.URL.Query()
+	if len(want) != len(got) {
+		return false
+	}
+	for k, values := range want {
+		if len(values) == 1 && values[0] == redacted {
+			if _, ok := got[k]; ok {
+				continue
+			}
+		}
+		if !reflect.DeepEqual(values, got[k]) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchBody compares bodies, semantically for JSON; redacted JSON values in
+// the recording match any value
+func MatchBody(_ *http.Request, body []byte, rec RecordedRequest) bool {
+	recBody, err := decodeRecordedBody(rec.Body, rec.BodyEncoding)
+	if err != nil {
+		return false
+	}
+	var want, got any
+	if json.Unmarshal(recBody, &want) == nil && json.Unmarshal(body, &got) == nil {
+		return jsonMatches(want, got)
+	}
+	return bytes.Equal(recBody, body)
+}
+
+// DefaultMatchers match on method, path and query
+var DefaultMatchers = []Matcher{MatchMethod, MatchPath, MatchQuery}
+
+func jsonMatches(want, got any) bool {
+	if want == redacted {
+		return true
+	}
+	switch w := want.(type) {
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		if !ok || len(g) != len(w) {
+			return false
+		}
+		for k, v := range w {
+			gv, ok := g[k]
+			if !ok || !jsonMatches(v, gv) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		g, ok := got.([]any)
+		if !ok || len(g) != len(w) {
+			return false
+		}
+		for i := range w {
+			if !jsonMatches(w[i], g[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(want, got)
+}
+
+// CassetteRedactor masks credentials and card numbers but keeps email
+// addresses, which tests commonly assert on
+func CassetteRedactor() *Redactor {
+	r := DefaultRedactor()
+	r.Scrubbers = []Scrubber{PANScrubber()}
+	r.BodyContentTypes = nil
+	return r
+}
+
+// Recorder is a cassette RoundTripper for offline client tests. In record
+// mode it captures real interactions, redacted, to a JSON file; in replay
+// mode it serves them back, each recorded interaction at most once and in
+// order among equal matches.
+type Recorder struct {
+	Proxied  http.RoundTripper
+	Path     string
+	Mode     CassetteMode
+	Matchers []Matcher // DefaultMatchers when empty
+	Redactor *Redactor // CassetteRedactor when nil
+
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+	dirty    bool
+}
+
+// NewRecorder loads the cassette at path; replay mode requires it to exist
+func NewRecorder(path string, mode CassetteMode, matchers ...Matcher) (*Recorder, error) {
+	if mode == ModeReplay {
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("cassette for replay: %w", err)
+		}
+	}
+	c, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	if mode == ModeRecord {
+		c = &Cassette{}
+	}
+	return &Recorder{
+		Proxied:  http.DefaultTransport,
+		Path:     path,
+		Mode:     mode,
+		Matchers: matchers,
+		cassette: c,
+		used:     make([]bool, len(c.Interactions)),
+	}, nil
+}
+
+// WithRecorder installs r beneath logging. Install it before WithAuth and
+// WithLimiter so it sits next to the network and records their headers.
+func (c *HTTPClient) WithRecorder(r *Recorder) *HTTPClient {
+	r.Proxied = c.Logging.Proxied
+	c.Logging.Proxied = r
+	return c
+}
+
+// Stop saves newly recorded interactions
+func (r *Recorder) Stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.dirty {
+		return nil
+	}
+	r.dirty = false
+	return r.cassette.Save(r.Path)
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+
+	if r.Mode != ModeRecord {
+		if resp, ok, err := r.replay(req, body); ok || err != nil {
+			return resp, err
+		}
+		if r.Mode == ModeReplay {
+			return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, req.Method, req.URL)
+		}
+	}
+
+	live := req.Clone(req.Context())
+	if body != nil {
+		live.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	resp, err := r.Proxied.RoundTrip(live)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	r.record(req, body, resp, respBody)
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, bool, error) {
+	matchers := r.Matchers
+	if len(matchers) == 0 {
+		matchers = DefaultMatchers
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+next:
+	for i, in := range r.cassette.Interactions {
+		if r.used[i] {
+			continue
+		}
+		for _, m := range matchers {
+			if !m(req, body, in.Request) {
+				continue next
+			}
+		}
+		r.used[i] = true
+		respBody, err := decodeRecordedBody(in.Response.Body, in.Response.BodyEncoding)
+		if err != nil {
+			return nil, false, fmt.Errorf("cassette %s interaction %d: %w", r.Path, i, err)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			StatusCode:    in.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        in.Response.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(respBody)),
+			ContentLength: int64(len(respBody)),
+			Request:       req,
+		}, true, nil
+	}
+	return nil, false, nil
+}
+
+func (r *Recorder) record(req *http.Request, body []byte, resp *http.Response, respBody []byte) {
+	redactor := r.Redactor
+	if redactor == nil {
+		redactor = CassetteRedactor()
+	}
+	in := Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    redactor.URL(req.URL),
+			Header: redactHeader(redactor, req.Header),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     redactHeader(redactor, resp.Header),
+		},
+	}
+	// Redaction can change the body length
+	in.Request.Header.Del("Content-Length")
+	in.Response.Header.Del("Content-Length")
+	in.Request.Body, in.Request.BodyEncoding = encodeRecordedBody(redactor, body)
+	in.Response.Body, in.Response.BodyEncoding = encodeRecordedBody(redactor, respBody)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, in)
+	// Interactions recorded in this run are not replayed again
+	r.used = append(r.used, true)
+	r.dirty = true
+}
+
+func redactHeader(redactor *Redactor, h http.Header) http.Header {
+	out := h.Clone()
+	for name := range out {
+		if redactor.maskHeader(name) {
+			out[name] = []string{redacted}
+		}
+	}
+	return out
+}
+
+func encodeRecordedBody(redactor *Redactor, body []byte) (string, string) {
+	if len(body) == 0 {
+		return "", ""
+	}
+	if !utf8.Valid(body) {
+		return base64.StdEncoding.EncodeToString(body), "base64"
+	}
+	return redactor.Body(body), ""
+}
+
+func decodeRecordedBody(body, encoding string) ([]byte, error) {
+	if encoding == "base64" {
+		return base64.StdEncoding.DecodeString(body)
+	}
+	return []byte(body), nil
+}
@@ -0,0 +1,149 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"azureclient/internal/model"
+)
+
+// replay installs the committed cassette beneath client
+func replay(t *testing.T, client *HTTPClient, name string) *Recorder {
+	t.Helper()
+	r, err := NewRecorder("testdata/cassettes/"+name+".json", ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.WithRecorder(r)
+	return r
+}
+
+// checkAllReplayed fails the test if any interaction in r went unused
+func checkAllReplayed(t *testing.T, r *Recorder) {
+	t.Helper()
+	for i, used := range r.used {
+		if !used {
+			in := r.cassette.Interactions[i]
+			t.Errorf("interaction %d (%s %s) was not replayed", i, in.Request.Method, in.Request.URL)
+		}
+	}
+}
+
+func TestPaymentClientCassette(t *testing.T) {
+	client := NewPaymentClient("https://payments.example.com")
+	r := replay(t, client.HTTPClient, "payment_client")
+	defer checkAllReplayed(t, r)
+	ctx := context.Background()
+	amount := NewMoney(1250, "THB")
+
+	intent, err := client.CreatePaymentIntent(ctx, CreatePaymentIntentRequest{Amount: amount, CaptureMethod: "manual"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if intent.ID != "pi_123" || intent.Status != PaymentStatusRequiresCapture || intent.Amount != amount {
+		t.Fatalf("created intent = %+v", intent)
+	}
+
+	captured, err := client.CapturePayment(ctx, intent.ID, CapturePaymentRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if captured.AmountCaptured != amount {
+		t.Fatalf("captured %s, want %s", captured.AmountCaptured, amount)
+	}
+
+	_, err = client.CapturePayment(ctx, "pi_missing", CapturePaymentRequest{})
+	var payErr *PaymentError
+	if !errors.As(err, &payErr) || payErr.API.Code != "resource_missing" || !IsStatus(err, http.StatusNotFound) {
+		t.Fatalf("capture missing intent: err = %v, want resource_missing 404", err)
+	}
+
+	refundAmount := NewMoney(500, "THB")
+	refund, err := client.RefundPayment(ctx, intent.ID, RefundPaymentRequest{Amount: &refundAmount, Reason: "requested_by_customer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refund.Status != RefundStatusPending || refund.Amount != refundAmount {
+		t.Fatalf("refund = %+v", refund)
+	}
+
+	// The cassette answers 503 first, which the retry policy absorbs
+	status, err := client.GetPaymentStatus(ctx, intent.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != PaymentStatusSucceeded {
+		t.Fatalf("status = %s, want succeeded", status.Status)
+	}
+
+	list, err := client.ListTransactions(ctx, ListTransactionsRequest{PaymentIntentID: intent.ID, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Data) != 1 || list.Data[0].Type != TransactionTypeCharge {
+		t.Fatalf("transactions = %+v", list.Data)
+	}
+}
+
+func TestMemberClientCassette(t *testing.T) {
+	client := NewMemberClient("https://members.example.com")
+	r := replay(t, client.HTTPClient, "member_client")
+	defer checkAllReplayed(t, r)
+	ctx := context.Background()
+
+	var names []string
+	it := client.Members(2)
+	for it.Next(ctx) {
+		names = append(names, it.Member().Name)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 3 || names[2] != "Grace Hopper" {
+		t.Fatalf("members = %v, want three across two pages", names)
+	}
+
+	member, err := client.GetMember(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if member.Email != "ada@example.com" {
+		t.Fatalf("member = %+v", member)
+	}
+
+	if _, err := client.GetMember(ctx, 404); !IsStatus(err, http.StatusNotFound) {
+		t.Fatalf("get missing member: err = %v, want 404", err)
+	}
+
+	created, err := client.CreateMember(ctx, model.Member{Name: "Ada Lovelace", Email: "ada@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.ID != 1 {
+		t.Fatalf("created = %+v", created)
+	}
+
+	updated, err := client.UpdateMember(ctx, model.Member{ID: 1, Name: "Ada King", Email: "ada@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Name != "Ada King" {
+		t.Fatalf("updated = %+v", updated)
+	}
+
+	if err := client.DeleteMember(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecorderReplayUnknownRequest(t *testing.T) {
+	client := NewMemberClient("https://members.example.com")
+	replay(t, client.HTTPClient, "member_client")
+	// Replay never reaches the network, so an unrecorded request fails
+	_, err := client.GetMember(context.Background(), 7)
+	if !errors.Is(err, ErrNoInteraction) {
+		t.Fatalf("err = %v, want ErrNoInteraction", err)
+	}
+}
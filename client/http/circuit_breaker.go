@@ -0,0 +1,329 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ErrCircuitOpen is returned, wrapped in a *CircuitOpenError, when a request
+// is rejected because the circuit for its host is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitOpenError reports which host is failing fast and until when
+type CircuitOpenError struct {
+	Host    string
+	RetryAt time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s for %s until %s", ErrCircuitOpen, e.Host, e.RetryAt.Format(time.RFC3339))
+}
+
+func (e *CircuitOpenError) Unwrap() error { return ErrCircuitOpen }
+
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+func (s CircuitState) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// CircuitBreakerSettings configures CircuitBreaker
+type CircuitBreakerSettings struct {
+	Window           time.Duration // failure rate is measured over this rolling window
+	Buckets          int           // window resolution
+	MinRequests      int           // no tripping below this many requests in the window
+	FailureRate      float64       // trip at or above this ratio of failures, 0..1
+	OpenTimeout      time.Duration // time spent open before probing
+	HalfOpenRequests int           // probes allowed while half-open; all must succeed to close
+	// IsFailure classifies an outcome; defaults to transport errors and 5xx
+	// responses, ignoring rejections by the client's own Limiter
+	IsFailure func(resp *http.Response, err error) bool
+	// OnStateChange is called after each transition, outside the breaker lock
+	OnStateChange func(host string, from, to CircuitState)
+}
+
+func DefaultCircuitBreakerSettings() CircuitBreakerSettings {
+	return CircuitBreakerSettings{
+		Window:           30 * time.Second,
+		Buckets:          10,
+		MinRequests:      10,
+		FailureRate:      0.5,
+		OpenTimeout:      15 * time.Second,
+		HalfOpenRequests: 3,
+	}
+}
+
+// CircuitBreaker tracks a circuit per downstream host
+type CircuitBreaker struct {
+	settings CircuitBreakerSettings
+
+	mu    sync.Mutex
+	hosts map[string]*circuit
+}
+
+func NewCircuitBreaker(settings CircuitBreakerSettings) *CircuitBreaker {
+	if settings.Buckets < 1 {
+		settings.Buckets = 1
+	}
+	if settings.HalfOpenRequests < 1 {
+		settings.HalfOpenRequests = 1
+	}
+	if settings.IsFailure == nil {
+		settings.IsFailure = func(resp *http.Response, err error) bool {
+			if err != nil {
+				return !isLocalRejection(err)
+			}
+			return resp.StatusCode >= 500
+		}
+	}
+	return &CircuitBreaker{settings: settings, hosts: map[string]*circuit{}}
+}
+
+// Middleware returns a Middleware enforcing the breaker
+func (cb *CircuitBreaker) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &circuitBreakerRoundTripper{Proxied: next, Breaker: cb}
+	}
+}
+
+// State returns the state for host
+func (cb *CircuitBreaker) State(host string) CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	c, ok := cb.hosts[host]
+	if !ok {
+		return CircuitClosed
+	}
+	return c.effectiveState(time.Now(), &cb.settings)
+}
+
+// States returns the state of every host seen so far
+func (cb *CircuitBreaker) States() map[string]CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	now := time.Now()
+	states := make(map[string]CircuitState, len(cb.hosts))
+	for host, c := range cb.hosts {
+		states[host] = c.effectiveState(now, &cb.settings)
+	}
+	return states
+}
+
+// HealthHandler reports host states as JSON, answering 503 while any circuit is open
+func (cb *CircuitBreaker) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		states := cb.States()
+		code := http.StatusOK
+		for _, s := range states {
+			if s == CircuitOpen {
+				code = http.StatusServiceUnavailable
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(states)
+	})
+}
+
+// allow admits or rejects a request, returning the transition it caused if any
+func (cb *CircuitBreaker) allow(host string) (*transition, error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	c, ok := cb.hosts[host]
+	if !ok {
+		c = newCircuit(cb.settings.Buckets)
+		cb.hosts[host] = c
+	}
+	now := time.Now()
+	t := c.advance(now, &cb.settings)
+	switch c.state {
+	case CircuitOpen:
+		return t, &CircuitOpenError{Host: host, RetryAt: c.openedAt.Add(cb.settings.OpenTimeout)}
+	case CircuitHalfOpen:
+		if c.probes >= cb.settings.HalfOpenRequests {
+			return t, &CircuitOpenError{Host: host, RetryAt: now.Add(time.Second)}
+		}
+		c.probes++
+	}
+	return t, nil
+}
+
+// record stores an outcome, returning the transition it caused if any.
+// Cancelled requests are not counted either way.
+func (cb *CircuitBreaker) record(host string, cancelled, failed bool) *transition {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	c := cb.hosts[host]
+	now := time.Now()
+	from := c.state
+	switch {
+	case cancelled:
+		if c.state == CircuitHalfOpen && c.probes > 0 {
+			c.probes--
+		}
+	case c.state == CircuitHalfOpen:
+		if failed {
+			c.open(now)
+		} else if c.successes++; c.successes >= cb.settings.HalfOpenRequests {
+			c.close()
+		}
+	case c.state == CircuitClosed:
+		c.add(now, failed, &cb.settings)
+		total, failures := c.totals()
+		if total >= cb.settings.MinRequests && float64(failures)/float64(total) >= cb.settings.FailureRate {
+			c.open(now)
+		}
+	}
+	if c.state != from {
+		return &transition{host: host, from: from, to: c.state}
+	}
+	return nil
+}
+
+func (cb *CircuitBreaker) notify(t *transition) {
+	if t == nil {
+		return
+	}
+	circuitTransitions.Add(context.Background(), 1, metric.WithAttributes(
+		attribute.String("server.address", t.host),
+		attribute.String("from", t.from.String()),
+		attribute.String("to", t.to.String()),
+	))
+	if cb.settings.OnStateChange != nil {
+		cb.settings.OnStateChange(t.host, t.from, t.to)
+	}
+}
+
+type transition struct {
+	host     string
+	from, to CircuitState
+}
+
+type bucket struct {
+	start           time.Time
+	total, failures int
+}
+
+type circuit struct {
+	state     CircuitState
+	openedAt  time.Time
+	probes    int
+	successes int
+	buckets   []bucket
+}
+
+func newCircuit(n int) *circuit {
+	return &circuit{buckets: make([]bucket, n)}
+}
+
+func (c *circuit) effectiveState(now time.Time, s *CircuitBreakerSettings) CircuitState {
+	if c.state == CircuitOpen && now.Sub(c.openedAt) >= s.OpenTimeout {
+		return CircuitHalfOpen
+	}
+	return c.state
+}
+
+// advance moves an open circuit to half-open once the timeout has passed
+func (c *circuit) advance(now time.Time, s *CircuitBreakerSettings) *transition {
+	if c.state == CircuitOpen && now.Sub(c.openedAt) >= s.OpenTimeout {
+		c.state = CircuitHalfOpen
+		c.probes, c.successes = 0, 0
+		return &transition{from: CircuitOpen, to: CircuitHalfOpen}
+	}
+	return nil
+}
+
+func (c *circuit) add(now time.Time, failed bool, s *CircuitBreakerSettings) {
+	width := s.Window / time.Duration(len(c.buckets))
+	if width <= 0 {
+		width = time.Second
+	}
+	start := now.Truncate(width)
+	b := &c.buckets[int(start.UnixNano()/int64(width))%len(c.buckets)]
+	if !b.start.Equal(start) {
+		*b = bucket{start: start}
+	}
+	b.total++
+	if failed {
+		b.failures++
+	}
+	// Forget buckets that have slid out of the window
+	for i := range c.buckets {
+		if now.Sub(c.buckets[i].start) >= s.Window {
+			c.buckets[i] = bucket{}
+		}
+	}
+}
+
+func (c *circuit) totals() (total, failures int) {
+	for _, b := range c.buckets {
+		total += b.total
+		failures += b.failures
+	}
+	return total, failures
+}
+
+func (c *circuit) open(now time.Time) {
+	c.state = CircuitOpen
+	c.openedAt = now
+}
+
+func (c *circuit) close() {
+	c.state = CircuitClosed
+	for i := range c.buckets {
+		c.buckets[i] = bucket{}
+	}
+}
+
+type circuitBreakerRoundTripper struct {
+	Proxied http.RoundTripper
+	Breaker *CircuitBreaker
+}
+
+func (crt *circuitBreakerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	t, err := crt.Breaker.allow(host)
+	if t != nil {
+		t.host = host
+		crt.Breaker.notify(t)
+	}
+	if err != nil {
+		circuitRejections.Add(req.Context(), 1, metric.WithAttributes(attribute.String("server.address", host)))
+		return nil, err
+	}
+	resp, err := crt.Proxied.RoundTrip(req)
+	// A request cancelled by the caller says nothing about the host's health,
+	// but one that ran out of time (a context deadline, Client.Timeout or a
+	// conn deadline) is the slow host the breaker is meant to catch
+	cancelled := errors.Is(req.Context().Err(), context.Canceled) &&
+		!errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, os.ErrDeadlineExceeded)
+	failed := !cancelled && crt.Breaker.settings.IsFailure(resp, err)
+	crt.Breaker.notify(crt.Breaker.record(host, cancelled, failed))
+	return resp, err
+}
@@ -0,0 +1,221 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// statusTransport answers every request with its current status, or err
+type statusTransport struct {
+	mu     sync.Mutex
+	status int
+	err    error
+	calls  int
+}
+
+func (s *statusTransport) set(status int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status, s.err = status, err
+}
+
+func (s *statusTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return response(req, s.status, nil), nil
+}
+
+type transitionLog struct {
+	mu  sync.Mutex
+	log []string
+}
+
+func (l *transitionLog) record(host string, from, to CircuitState) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.log = append(l.log, fmt.Sprintf("%s->%s", from, to))
+}
+
+func (l *transitionLog) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return fmt.Sprint(l.log)
+}
+
+func newTestBreaker(transitions *transitionLog) *CircuitBreaker {
+	return NewCircuitBreaker(CircuitBreakerSettings{
+		Window:           time.Minute,
+		Buckets:          1,
+		MinRequests:      4,
+		FailureRate:      0.5,
+		OpenTimeout:      20 * time.Millisecond,
+		HalfOpenRequests: 2,
+		OnStateChange:    transitions.record,
+	})
+}
+
+func do(t *testing.T, rt http.RoundTripper, ctx context.Context) error {
+	t.Helper()
+	req, _ := http.NewRequestWithContext(ctx, "GET", "http://payments.example.com/x", nil)
+	_, err := rt.RoundTrip(req)
+	return err
+}
+
+func TestCircuitBreakerStateMachine(t *testing.T) {
+	transitions := &transitionLog{}
+	cb := newTestBreaker(transitions)
+	transport := &statusTransport{status: http.StatusOK}
+	rt := cb.Middleware()(transport)
+	ctx := context.Background()
+	host := "payments.example.com"
+
+	// Two successes and one failure: under MinRequests, stays closed
+	do(t, rt, ctx)
+	do(t, rt, ctx)
+	transport.set(http.StatusServiceUnavailable, nil)
+	do(t, rt, ctx)
+	if s := cb.State(host); s != CircuitClosed {
+		t.Fatalf("state = %s after 3 requests, want closed", s)
+	}
+
+	// The fourth request brings failures to 50% and trips the breaker
+	do(t, rt, ctx)
+	if s := cb.State(host); s != CircuitOpen {
+		t.Fatalf("state = %s, want open", s)
+	}
+	calls := transport.calls
+	err := do(t, rt, ctx)
+	var openErr *CircuitOpenError
+	if !errors.Is(err, ErrCircuitOpen) || !errors.As(err, &openErr) || openErr.Host != host {
+		t.Fatalf("err = %v, want CircuitOpenError for %s", err, host)
+	}
+	if transport.calls != calls {
+		t.Fatal("request reached the host while the circuit was open")
+	}
+
+	// After OpenTimeout a failed probe reopens the circuit
+	time.Sleep(25 * time.Millisecond)
+	if s := cb.State(host); s != CircuitHalfOpen {
+		t.Fatalf("state = %s after OpenTimeout, want half-open", s)
+	}
+	do(t, rt, ctx)
+	if s := cb.State(host); s != CircuitOpen {
+		t.Fatalf("state = %s after a failed probe, want open", s)
+	}
+
+	// HalfOpenRequests successful probes close it again
+	time.Sleep(25 * time.Millisecond)
+	transport.set(http.StatusOK, nil)
+	do(t, rt, ctx)
+	if s := cb.State(host); s != CircuitHalfOpen {
+		t.Fatalf("state = %s after one probe, want half-open", s)
+	}
+	do(t, rt, ctx)
+	if s := cb.State(host); s != CircuitClosed {
+		t.Fatalf("state = %s after two probes, want closed", s)
+	}
+
+	want := "[closed->open open->half-open half-open->open open->half-open half-open->closed]"
+	if got := transitions.String(); got != want {
+		t.Fatalf("transitions = %s, want %s", got, want)
+	}
+}
+
+func TestCircuitBreakerHalfOpenLimitsProbes(t *testing.T) {
+	cb := newTestBreaker(&transitionLog{})
+	host := "payments.example.com"
+	cb.allow(host)
+	cb.record(host, false, true)
+	cb.hosts[host].open(time.Now().Add(-time.Minute))
+
+	for i := 0; i < 2; i++ {
+		if _, err := cb.allow(host); err != nil {
+			t.Fatalf("probe %d rejected: %v", i+1, err)
+		}
+	}
+	if _, err := cb.allow(host); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("third probe err = %v, want ErrCircuitOpen", err)
+	}
+	// A cancelled probe frees its slot
+	cb.record(host, true, false)
+	if _, err := cb.allow(host); err != nil {
+		t.Fatalf("probe after a cancellation rejected: %v", err)
+	}
+}
+
+func TestCircuitBreakerIgnoresCancellationsAndLocalRejections(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  func() context.Context
+		err  error
+	}{
+		{
+			name: "cancelled by the caller",
+			ctx: func() context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx
+			},
+			err: context.Canceled,
+		},
+		{name: "rejected by the client rate limit", ctx: context.Background, err: ErrRateLimited},
+		{name: "rejected by the bulkhead", ctx: context.Background, err: ErrBulkheadFull},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cb := newTestBreaker(&transitionLog{})
+			rt := cb.Middleware()(&statusTransport{err: tt.err})
+			for i := 0; i < 10; i++ {
+				do(t, rt, tt.ctx())
+			}
+			if s := cb.State("payments.example.com"); s != CircuitClosed {
+				t.Fatalf("state = %s, want closed", s)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerCountsTimeouts(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+
+	transitions := &transitionLog{}
+	cb := NewCircuitBreaker(CircuitBreakerSettings{
+		Window:        time.Minute,
+		Buckets:       1,
+		MinRequests:   2,
+		FailureRate:   0.5,
+		OpenTimeout:   time.Minute,
+		OnStateChange: transitions.record,
+	})
+	client := &http.Client{Transport: cb.Middleware()(http.DefaultTransport), Timeout: 50 * time.Millisecond}
+	host := srv.Listener.Addr().String()
+	for i := 0; i < 2; i++ {
+		if _, err := client.Get(srv.URL); err == nil {
+			t.Fatal("request to a hanging server succeeded")
+		}
+	}
+	if s := cb.State(host); s != CircuitOpen {
+		t.Fatalf("state = %s after timeouts, want open (transitions %s)", s, transitions)
+	}
+	if got, want := transitions.String(), "[closed->open]"; got != want {
+		t.Errorf("transitions = %s, want %s", got, want)
+	}
+}
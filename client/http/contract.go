@@ -0,0 +1,474 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Contract is a consumer-driven contract: the requests a consumer sends to
+// a provider and the parts of each response it relies on. Consumers record
+// contracts against a MockProvider; providers are checked with a
+// ContractVerifier.
+type Contract struct {
+	Consumer     string                `json:"consumer"`
+	Provider     string                `json:"provider"`
+	Interactions []ContractInteraction `json:"interactions"`
+}
+
+// ContractInteraction is one request and the response expected for it
+// while the provider is in State
+type ContractInteraction struct {
+	Description string           `json:"description"`
+	State       string           `json:"providerState,omitempty"`
+	Request     ContractRequest  `json:"request"`
+	Response    ContractResponse `json:"response"`
+}
+
+// ContractRequest is the request shape. {name} placeholders in Path and
+// Query values are filled from Params, whose values are examples the
+// verifier replaces with the ones returned by provider state setup.
+type ContractRequest struct {
+	Method string            `json:"method"`
+	Path   string            `json:"path"`
+	Params map[string]string `json:"params,omitempty"`
+	Query  map[string]string `json:"query,omitempty"`
+	// Header values of "*" only require the header to be present
+	Header map[string]string `json:"header,omitempty"`
+	// Body lists the JSON fields the request must contain, with their values
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// ContractResponse is the expected response. Body is the example served by
+// a MockProvider; the verifier only checks that the Required fields exist
+// with the example's JSON types, and that the Header names are present.
+type ContractResponse struct {
+	Status int               `json:"status"`
+	Header map[string]string `json:"header,omitempty"`
+	Body   json.RawMessage   `json:"body,omitempty"`
+	// Required are field paths the consumer reads, e.g. "data[].amount.currency";
+	// a leading "[]" addresses the elements of an array body
+	Required []string `json:"required,omitempty"`
+}
+
+// LoadContract reads a contract file
+func LoadContract(path string) (*Contract, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read contract: %w", err)
+	}
+	var c Contract
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse contract %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// Save writes the contract, creating its directory if needed
+func (c *Contract) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create contract directory: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// RequiredFields lists the JSON field paths of v's type that are not
+// omitempty, for use as ContractResponse.Required
+func RequiredFields(v any) []string {
+	return requiredFields(reflect.TypeOf(v), "")
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func requiredFields(t reflect.Type, prefix string) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8:
+		return requiredFields(t.Elem(), prefix+"[]")
+	case t.Kind() != reflect.Struct || t == timeType:
+		if prefix == "" {
+			return nil
+		}
+		return []string{prefix}
+	}
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || strings.Contains(opts, "omitempty") {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+		fields = append(fields, requiredFields(f.Type, path)...)
+	}
+	return fields
+}
+
+// expand fills the {name} placeholders in s
+func expand(s string, params map[string]string) string {
+	for name, value := range params {
+		s = strings.ReplaceAll(s, "{"+name+"}", value)
+	}
+	return s
+}
+
+// mismatches lists how req differs from the expected request
+func (cr ContractRequest) mismatches(req *http.Request, body []byte) []string {
+	var problems []string
+	if req.Method != cr.Method {
+		problems = append(problems, fmt.Sprintf("method %s, want %s", req.Method, cr.Method))
+	}
+	if path := expand(cr.Path, cr.Params); req.URL.Path != path {
+		problems = append(problems, fmt.Sprintf("path %s, want %s", req.URL.Path, path))
+	}
+	query := req.URL.Query()
+	for _, name := range sortedKeys(cr.Query) {
+		if got, want := query.Get(name), expand(cr.Query[name], cr.Params); got != want {
+			problems = append(problems, fmt.Sprintf("query %s=%q, want %q", name, got, want))
+		}
+	}
+	for _, name := range sortedKeys(cr.Header) {
+		got, want := req.Header.Get(name), cr.Header[name]
+		if got == "" || (want != "*" && got != want) {
+			problems = append(problems, fmt.Sprintf("header %s=%q, want %q", name, got, want))
+		}
+	}
+	if len(cr.Body) > 0 {
+		var want, got any
+		if err := json.Unmarshal(cr.Body, &want); err != nil {
+			return append(problems, fmt.Sprintf("invalid expected body: %v", err))
+		}
+		if err := json.Unmarshal(body, &got); err != nil {
+			return append(problems, fmt.Sprintf("body is not JSON: %v", err))
+		}
+		problems = append(problems, subsetMismatches(want, got, "body")...)
+	}
+	return problems
+}
+
+// subsetMismatches reports where got lacks the fields and values of want
+func subsetMismatches(want, got any, path string) []string {
+	switch w := want.(type) {
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s is %s, want object", path, jsonKind(got))}
+		}
+		var problems []string
+		for _, k := range sortedKeys(w) {
+			v, ok := g[k]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s.%s is missing", path, k))
+				continue
+			}
+			problems = append(problems, subsetMismatches(w[k], v, path+"."+k)...)
+		}
+		return problems
+	case []any:
+		g, ok := got.([]any)
+		if !ok || len(g) != len(w) {
+			return []string{fmt.Sprintf("%s does not match %v", path, want)}
+		}
+		var problems []string
+		for i := range w {
+			problems = append(problems, subsetMismatches(w[i], g[i], fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return problems
+	}
+	if !reflect.DeepEqual(want, got) {
+		return []string{fmt.Sprintf("%s is %v, want %v", path, got, want)}
+	}
+	return nil
+}
+
+// fieldMismatches checks the required field paths of body against example
+func fieldMismatches(body, example any, required []string) []string {
+	var problems []string
+	for _, path := range required {
+		var tokens []string
+		for _, part := range strings.Split(path, ".") {
+			if name, ok := strings.CutSuffix(part, "[]"); ok {
+				if name != "" {
+					tokens = append(tokens, name)
+				}
+				tokens = append(tokens, "[]")
+			} else {
+				tokens = append(tokens, part)
+			}
+		}
+		problems = append(problems, checkField(body, example, tokens, path)...)
+	}
+	return problems
+}
+
+func checkField(v, example any, tokens []string, path string) []string {
+	if len(tokens) == 0 {
+		if v != nil && example != nil && jsonKind(v) != jsonKind(example) {
+			return []string{fmt.Sprintf("%s is %s, want %s", path, jsonKind(v), jsonKind(example))}
+		}
+		return nil
+	}
+	if tokens[0] == "[]" {
+		arr, ok := v.([]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: %s is not an array", path, jsonKind(v))}
+		}
+		var elem any
+		if ex, ok := example.([]any); ok && len(ex) > 0 {
+			elem = ex[0]
+		}
+		var problems []string
+		for _, item := range arr {
+			problems = append(problems, checkField(item, elem, tokens[1:], path)...)
+		}
+		return problems
+	}
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return []string{fmt.Sprintf("%s: %s is not an object", path, jsonKind(v))}
+	}
+	field, ok := obj[tokens[0]]
+	if !ok {
+		return []string{fmt.Sprintf("%s is missing", path)}
+	}
+	var exField any
+	if ex, ok := example.(map[string]any); ok {
+		exField = ex[tokens[0]]
+	}
+	return checkField(field, exField, tokens[1:], path)
+}
+
+func jsonKind(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64, json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// MockProvider serves a contract's example responses. Consumers point their
+// client at it and call Verify to check every interaction was exercised and
+// no unexpected request was sent; it doubles as an offline provider stub.
+type MockProvider struct {
+	contract *Contract
+
+	mu         sync.Mutex
+	used       []bool
+	unexpected []string
+}
+
+func NewMockProvider(c *Contract) *MockProvider {
+	return &MockProvider{contract: c, used: make([]bool, len(c.Interactions))}
+}
+
+func (m *MockProvider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m.mu.Lock()
+	match, closest := -1, []string(nil)
+	for i, in := range m.contract.Interactions {
+		problems := in.Request.mismatches(r, body)
+		if len(problems) == 0 && (match < 0 || (m.used[match] && !m.used[i])) {
+			match = i
+		}
+		if len(problems) > 0 && (closest == nil || len(problems) < len(closest)) {
+			closest = problems
+		}
+	}
+	if match < 0 {
+		msg := fmt.Sprintf("unexpected request %s %s", r.Method, r.URL.RequestURI())
+		if closest != nil {
+			msg += ": " + strings.Join(closest, "; ")
+		}
+		m.unexpected = append(m.unexpected, msg)
+		m.mu.Unlock()
+		http.Error(w, msg, http.StatusInternalServerError)
+		return
+	}
+	m.used[match] = true
+	m.mu.Unlock()
+
+	resp := m.contract.Interactions[match].Response
+	for name, value := range resp.Header {
+		w.Header().Set(name, value)
+	}
+	if len(resp.Body) > 0 && w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(resp.Status)
+	w.Write(resp.Body)
+}
+
+// Verify reports interactions that were never exercised and requests that
+// matched none
+func (m *MockProvider) Verify() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var errs []error
+	for _, msg := range m.unexpected {
+		errs = append(errs, errors.New(msg))
+	}
+	for i, used := range m.used {
+		if !used {
+			errs = append(errs, fmt.Errorf("interaction %q was not exercised", m.contract.Interactions[i].Description))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ProviderStates puts the provider in state before an interaction is
+// replayed and returns the values for the request's placeholders; nil
+// values keep the contract's examples
+type ProviderStates func(ctx context.Context, state string) (map[string]string, error)
+
+// ContractVerifier replays a contract against a provider and checks the
+// responses. Header is sent with every request, e.g. for credentials.
+type ContractVerifier struct {
+	BaseURL string
+	Client  *http.Client
+	Header  http.Header
+	States  ProviderStates
+}
+
+// Verify replays every interaction and returns one error per failing one
+func (v *ContractVerifier) Verify(ctx context.Context, c *Contract) error {
+	var errs []error
+	for _, in := range c.Interactions {
+		if problems := v.verify(ctx, in); len(problems) > 0 {
+			errs = append(errs, fmt.Errorf("%s: %s", in.Description, strings.Join(problems, "; ")))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (v *ContractVerifier) verify(ctx context.Context, in ContractInteraction) []string {
+	params := map[string]string{}
+	maps.Copy(params, in.Request.Params)
+	if in.State != "" && v.States != nil {
+		values, err := v.States(ctx, in.State)
+		if err != nil {
+			return []string{fmt.Sprintf("provider state %q: %v", in.State, err)}
+		}
+		for k, val := range values {
+			params[k] = val
+		}
+	}
+
+	u := strings.TrimSuffix(v.BaseURL, "/") + expand(in.Request.Path, params)
+	if len(in.Request.Query) > 0 {
+		q := url.Values{}
+		for name, value := range in.Request.Query {
+			q.Set(name, expand(value, params))
+		}
+		u += "?" + q.Encode()
+	}
+	var body io.Reader
+	if len(in.Request.Body) > 0 {
+		body = bytes.NewReader(in.Request.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, in.Request.Method, u, body)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	for name, values := range v.Header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range in.Request.Header {
+		if value == "*" {
+			value = uuid.New().String()
+		}
+		req.Header.Set(name, value)
+	}
+
+	client := v.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return []string{fmt.Sprintf("failed to read response body: %v", err)}
+	}
+
+	var problems []string
+	if resp.StatusCode != in.Response.Status {
+		problems = append(problems, fmt.Sprintf("status %d, want %d", resp.StatusCode, in.Response.Status))
+	}
+	for _, name := range sortedKeys(in.Response.Header) {
+		if resp.Header.Get(name) == "" {
+			problems = append(problems, fmt.Sprintf("header %s is missing", name))
+		}
+	}
+	if len(in.Response.Required) > 0 {
+		var got, example any
+		if err := json.Unmarshal(data, &got); err != nil {
+			return append(problems, fmt.Sprintf("body is not JSON: %v", err))
+		}
+		if len(in.Response.Body) > 0 {
+			json.Unmarshal(in.Response.Body, &example)
+		}
+		problems = append(problems, fieldMismatches(got, example, in.Response.Required)...)
+	}
+	return problems
+}
@@ -0,0 +1,451 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"azureclient/internal/model"
+)
+
+var updateContracts = flag.Bool("update-contracts", false, "rewrite the contracts under contracts/")
+
+// TestContracts runs each client against a MockProvider serving its
+// expectations and checks them against the committed contract, which
+// cmd/contract verifies against the live provider
+func TestContracts(t *testing.T) {
+	tests := []struct {
+		name     string
+		contract *Contract
+		exercise func(t *testing.T, ctx context.Context, baseURL string)
+	}{
+		{name: "member-api", contract: memberContract(), exercise: exerciseMemberClient},
+		{name: "payment-api", contract: paymentContract(), exercise: exercisePaymentClient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := NewMockProvider(tt.contract)
+			srv := httptest.NewServer(mock)
+			defer srv.Close()
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			tt.exercise(t, ctx, srv.URL)
+			if err := mock.Verify(); err != nil {
+				t.Fatal(err)
+			}
+
+			path := "../../contracts/" + tt.name + ".json"
+			if *updateContracts {
+				if err := tt.contract.Save(path); err != nil {
+					t.Fatal(err)
+				}
+			}
+			committed, err := LoadContract(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _ := json.MarshalIndent(tt.contract, "", "  ")
+			want, _ := json.MarshalIndent(committed, "", "  ")
+			if string(got) != string(want) {
+				t.Fatalf("expectations differ from %s; rerun with -update-contracts and review the diff:\n%s", path, got)
+			}
+
+			// The committed contract must hold against a stub built from itself
+			stub := httptest.NewServer(NewMockProvider(committed))
+			defer stub.Close()
+			v := &ContractVerifier{BaseURL: stub.URL}
+			if err := v.Verify(ctx, committed); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestContractVerifierReportsMismatches(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id": "1", "name": "Ada Lovelace"}]`))
+	}))
+	defer srv.Close()
+
+	v := &ContractVerifier{BaseURL: srv.URL}
+	err := v.Verify(context.Background(), &Contract{Interactions: memberContract().Interactions[:1]})
+	if err == nil {
+		t.Fatal("Verify() = nil, want mismatches")
+	}
+	for _, want := range []string{"header X-Next-Cursor is missing", "[].id", "[].email"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestMockProviderReportsUnexercisedAndUnexpected(t *testing.T) {
+	mock := NewMockProvider(memberContract())
+	srv := httptest.NewServer(mock)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/members/1/avatar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500 for an unexpected request", resp.StatusCode)
+	}
+	err = mock.Verify()
+	if err == nil || !strings.Contains(err.Error(), "unexpected request GET /members/1/avatar") || !strings.Contains(err.Error(), `interaction "create a member" was not exercised`) {
+		t.Fatalf("Verify() = %v, want unexpected and unexercised interactions", err)
+	}
+}
+
+const (
+	memberExists     = "a member exists"
+	membersExist     = "at least three members exist"
+	memberNotExists  = "the member does not exist"
+	exampleMemberID  = 1
+	missingMemberID  = 404
+	exampleNextAfter = 2
+)
+
+var exampleMember = model.Member{ID: exampleMemberID, Name: "Ada Lovelace", Email: "ada@example.com"}
+
+func memberContract() *Contract {
+	return &Contract{
+		Consumer: "azureclient",
+		Provider: "member-api",
+		Interactions: []ContractInteraction{
+			{
+				Description: "list the first page of members",
+				State:       membersExist,
+				Request: ContractRequest{
+					Method: http.MethodGet,
+					Path:   "/members",
+					Query:  map[string]string{"limit": "2"},
+				},
+				Response: ContractResponse{
+					Status:   http.StatusOK,
+					Header:   map[string]string{"X-Next-Cursor": strconv.Itoa(exampleNextAfter)},
+					Body:     mustJSON([]model.Member{exampleMember, {ID: 2, Name: "Alan Turing", Email: "alan@example.com"}}),
+					Required: RequiredFields([]model.Member{}),
+				},
+			},
+			{
+				Description: "get a member",
+				State:       memberExists,
+				Request: ContractRequest{
+					Method: http.MethodGet,
+					Path:   "/members/{id}",
+					Params: map[string]string{"id": strconv.Itoa(exampleMemberID)},
+				},
+				Response: ContractResponse{
+					Status:   http.StatusOK,
+					Body:     mustJSON(exampleMember),
+					Required: RequiredFields(model.Member{}),
+				},
+			},
+			{
+				Description: "get a member that does not exist",
+				State:       memberNotExists,
+				Request: ContractRequest{
+					Method: http.MethodGet,
+					Path:   "/members/{id}",
+					Params: map[string]string{"id": strconv.Itoa(missingMemberID)},
+				},
+				Response: ContractResponse{
+					Status:   http.StatusNotFound,
+					Body:     mustJSON(map[string]any{"status": 4040, "message": "not found"}),
+					Required: []string{"status", "message"},
+				},
+			},
+			{
+				Description: "create a member",
+				Request: ContractRequest{
+					Method: http.MethodPost,
+					Path:   "/members",
+					Body:   mustJSON(map[string]string{"name": exampleMember.Name, "email": exampleMember.Email}),
+				},
+				Response: ContractResponse{
+					Status:   http.StatusCreated,
+					Body:     mustJSON(exampleMember),
+					Required: RequiredFields(model.Member{}),
+				},
+			},
+			{
+				Description: "update a member",
+				State:       memberExists,
+				Request: ContractRequest{
+					Method: http.MethodPut,
+					Path:   "/members/{id}",
+					Params: map[string]string{"id": strconv.Itoa(exampleMemberID)},
+					Body:   mustJSON(map[string]string{"name": "Ada King", "email": exampleMember.Email}),
+				},
+				Response: ContractResponse{
+					Status:   http.StatusOK,
+					Body:     mustJSON(model.Member{ID: exampleMemberID, Name: "Ada King", Email: exampleMember.Email}),
+					Required: RequiredFields(model.Member{}),
+				},
+			},
+			{
+				Description: "delete a member",
+				State:       memberExists,
+				Request: ContractRequest{
+					Method: http.MethodDelete,
+					Path:   "/members/{id}",
+					Params: map[string]string{"id": strconv.Itoa(exampleMemberID)},
+				},
+				Response: ContractResponse{Status: http.StatusNoContent},
+			},
+		},
+	}
+}
+
+// exerciseMemberClient makes the calls the contract describes and checks
+// MemberClient reads the responses as expected
+func exerciseMemberClient(t *testing.T, ctx context.Context, baseURL string) {
+	client := NewMemberClient(baseURL)
+
+	page, err := client.ListMembers(ctx, ListMembersRequest{Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Members) != 2 || page.NextCursor != exampleNextAfter {
+		t.Fatalf("list members: got %d members and cursor %d", len(page.Members), page.NextCursor)
+	}
+
+	member, err := client.GetMember(ctx, exampleMemberID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *member != exampleMember {
+		t.Fatalf("get member: got %+v", *member)
+	}
+
+	if _, err := client.GetMember(ctx, missingMemberID); !IsStatus(err, http.StatusNotFound) {
+		t.Fatalf("get missing member: want 404, got %v", err)
+	}
+
+	created, err := client.CreateMember(ctx, model.Member{Name: exampleMember.Name, Email: exampleMember.Email})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.ID == 0 {
+		t.Fatal("create member: no ID in response")
+	}
+
+	if _, err := client.UpdateMember(ctx, model.Member{ID: exampleMemberID, Name: "Ada King", Email: exampleMember.Email}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.DeleteMember(ctx, exampleMemberID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+const (
+	intentRequiresCapture = "a payment intent requiring capture exists"
+	intentCaptured        = "a captured payment intent exists"
+	intentNotExists       = "the payment intent does not exist"
+	exampleIntentID       = "pi_123"
+	missingIntentID       = "pi_missing"
+)
+
+var (
+	exampleAmount  = NewMoney(1250, "THB")
+	exampleCreated = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	intentIDParam  = map[string]string{"id": exampleIntentID}
+	intentFields   = RequiredFields(PaymentIntent{})
+)
+
+func paymentIntent(status PaymentStatus, captured Money) PaymentIntent {
+	return PaymentIntent{
+		ID:             exampleIntentID,
+		Amount:         exampleAmount,
+		AmountCaptured: captured,
+		AmountRefunded: Money{Currency: "THB"},
+		Status:         status,
+		CaptureMethod:  "manual",
+		CreatedAt:      exampleCreated,
+		UpdatedAt:      exampleCreated,
+	}
+}
+
+func paymentContract() *Contract {
+	exampleIntent := paymentIntent(PaymentStatusRequiresCapture, Money{Currency: "THB"})
+	return &Contract{
+		Consumer: "azureclient",
+		Provider: "payment-api",
+		Interactions: []ContractInteraction{
+			{
+				Description: "create a manually captured payment intent",
+				Request: ContractRequest{
+					Method: http.MethodPost,
+					Path:   "/payments/intents",
+					Header: map[string]string{"Idempotency-Key": "*"},
+					Body:   mustJSON(map[string]any{"amount": exampleAmount, "capture_method": "manual"}),
+				},
+				Response: ContractResponse{
+					Status:   http.StatusOK,
+					Body:     mustJSON(exampleIntent),
+					Required: intentFields,
+				},
+			},
+			{
+				Description: "capture a payment intent",
+				State:       intentRequiresCapture,
+				Request: ContractRequest{
+					Method: http.MethodPost,
+					Path:   "/payments/intents/{id}/capture",
+					Params: intentIDParam,
+					Header: map[string]string{"Idempotency-Key": "*"},
+					Body:   mustJSON(map[string]any{}),
+				},
+				Response: ContractResponse{
+					Status:   http.StatusOK,
+					Body:     mustJSON(paymentIntent(PaymentStatusSucceeded, exampleAmount)),
+					Required: intentFields,
+				},
+			},
+			{
+				Description: "capture a payment intent that does not exist",
+				State:       intentNotExists,
+				Request: ContractRequest{
+					Method: http.MethodPost,
+					Path:   "/payments/intents/{id}/capture",
+					Params: map[string]string{"id": missingIntentID},
+					Header: map[string]string{"Idempotency-Key": "*"},
+				},
+				Response: ContractResponse{
+					Status:   http.StatusNotFound,
+					Body:     mustJSON(map[string]any{"error": map[string]string{"code": "resource_missing", "message": "No such payment intent"}}),
+					Required: []string{"error.code", "error.message"},
+				},
+			},
+			{
+				Description: "refund part of a captured payment",
+				State:       intentCaptured,
+				Request: ContractRequest{
+					Method: http.MethodPost,
+					Path:   "/payments/intents/{id}/refunds",
+					Params: intentIDParam,
+					Header: map[string]string{"Idempotency-Key": "*"},
+					Body:   mustJSON(map[string]any{"amount": NewMoney(500, "THB"), "reason": "requested_by_customer"}),
+				},
+				Response: ContractResponse{
+					Status: http.StatusOK,
+					Body: mustJSON(Refund{
+						ID:              "re_123",
+						PaymentIntentID: exampleIntentID,
+						Amount:          NewMoney(500, "THB"),
+						Status:          RefundStatusPending,
+						CreatedAt:       exampleCreated,
+					}),
+					Required: RequiredFields(Refund{}),
+				},
+			},
+			{
+				Description: "get the status of a payment intent",
+				State:       intentRequiresCapture,
+				Request: ContractRequest{
+					Method: http.MethodGet,
+					Path:   "/payments/intents/{id}",
+					Params: intentIDParam,
+				},
+				Response: ContractResponse{
+					Status:   http.StatusOK,
+					Body:     mustJSON(exampleIntent),
+					Required: intentFields,
+				},
+			},
+			{
+				Description: "list the transactions of a payment intent",
+				State:       intentCaptured,
+				Request: ContractRequest{
+					Method: http.MethodGet,
+					Path:   "/payments/transactions",
+					Params: intentIDParam,
+					Query:  map[string]string{"payment_intent_id": "{id}", "limit": "10"},
+				},
+				Response: ContractResponse{
+					Status: http.StatusOK,
+					Body: mustJSON(TransactionList{Data: []Transaction{{
+						ID:              "txn_123",
+						PaymentIntentID: exampleIntentID,
+						Type:            TransactionTypeCharge,
+						Amount:          exampleAmount,
+						Status:          "succeeded",
+						CreatedAt:       exampleCreated,
+					}}}),
+					Required: RequiredFields(TransactionList{}),
+				},
+			},
+		},
+	}
+}
+
+// exercisePaymentClient makes the calls the contract describes and checks
+// PaymentClient reads the responses as expected
+func exercisePaymentClient(t *testing.T, ctx context.Context, baseURL string) {
+	client := NewPaymentClient(baseURL)
+
+	intent, err := client.CreatePaymentIntent(ctx, CreatePaymentIntentRequest{Amount: exampleAmount, CaptureMethod: "manual"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if intent.Status != PaymentStatusRequiresCapture || intent.Amount != exampleAmount {
+		t.Fatalf("create payment intent: got %s %s", intent.Status, intent.Amount)
+	}
+
+	captured, err := client.CapturePayment(ctx, exampleIntentID, CapturePaymentRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if captured.AmountCaptured != exampleAmount {
+		t.Fatalf("capture payment: captured %s", captured.AmountCaptured)
+	}
+
+	_, err = client.CapturePayment(ctx, missingIntentID, CapturePaymentRequest{})
+	var payErr *PaymentError
+	if !errors.As(err, &payErr) || payErr.API.Code != "resource_missing" {
+		t.Fatalf("capture missing payment: want resource_missing, got %v", err)
+	}
+
+	refundAmount := NewMoney(500, "THB")
+	refund, err := client.RefundPayment(ctx, exampleIntentID, RefundPaymentRequest{Amount: &refundAmount, Reason: "requested_by_customer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refund.PaymentIntentID != exampleIntentID {
+		t.Fatalf("refund payment: refund for %s", refund.PaymentIntentID)
+	}
+
+	status, err := client.GetPaymentStatus(ctx, exampleIntentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.ID != exampleIntentID {
+		t.Fatalf("get payment status: got intent %s", status.ID)
+	}
+
+	list, err := client.ListTransactions(ctx, ListTransactionsRequest{PaymentIntentID: exampleIntentID, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Data) != 1 {
+		t.Fatalf("list transactions: got %d transactions", len(list.Data))
+	}
+}
+
+func mustJSON(v any) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
@@ -0,0 +1,285 @@
+package http
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ErrInjectedFault is wrapped by the connection errors FaultInjector raises
+var ErrInjectedFault = errors.New("injected fault")
+
+// Fault kinds; latency may accompany any of them
+const (
+	FaultNone     = ""         // latency only
+	FaultError    = "error"    // connection error before the request is sent
+	FaultStatus   = "status"   // synthetic response with StatusCode
+	FaultTruncate = "truncate" // real response cut off after TruncateAt bytes
+	FaultTimeout  = "timeout"  // hang until the request deadline
+)
+
+// maxInjectedTimeout bounds FaultTimeout for requests without a deadline
+const maxInjectedTimeout = time.Minute
+
+// FaultRule injects a fault into matching requests
+type FaultRule struct {
+	Name        string  `json:"name"`
+	Method      string  `json:"method,omitempty"`     // empty matches any
+	Host        string  `json:"host,omitempty"`       // empty matches any
+	PathPrefix  string  `json:"pathPrefix,omitempty"` // empty matches any
+	Probability float64 `json:"probability"`          // chance of applying to a match, 0..1
+	LatencyMs   int     `json:"latencyMs,omitempty"`
+	JitterMs    int     `json:"jitterMs,omitempty"` // random extra latency up to this
+	Fault       string  `json:"fault,omitempty"`
+	StatusCode  int     `json:"statusCode,omitempty"`    // FaultStatus, defaults to 503
+	TruncateAt  int     `json:"truncateBytes,omitempty"` // FaultTruncate
+}
+
+func (r *FaultRule) validate() error {
+	if r.Probability < 0 || r.Probability > 1 {
+		return fmt.Errorf("fault rule %q: probability must be between 0 and 1", r.Name)
+	}
+	switch r.Fault {
+	case FaultNone, FaultError, FaultTimeout, FaultTruncate:
+	case FaultStatus:
+		if r.StatusCode != 0 && (r.StatusCode < 100 || r.StatusCode > 599) {
+			return fmt.Errorf("fault rule %q: invalid status code %d", r.Name, r.StatusCode)
+		}
+	default:
+		return fmt.Errorf("fault rule %q: unknown fault %q", r.Name, r.Fault)
+	}
+	return nil
+}
+
+func (r *FaultRule) matches(req *http.Request) bool {
+	return (r.Method == "" || strings.EqualFold(r.Method, req.Method)) &&
+		(r.Host == "" || r.Host == req.URL.Host) &&
+		strings.HasPrefix(req.URL.Path, r.PathPrefix)
+}
+
+// ParseFaultRules parses a JSON array of rules, as used in config
+func ParseFaultRules(data string) ([]FaultRule, error) {
+	if strings.TrimSpace(data) == "" {
+		return nil, nil
+	}
+	var rules []FaultRule
+	if err := json.Unmarshal([]byte(data), &rules); err != nil {
+		return nil, fmt.Errorf("invalid fault rules: %w", err)
+	}
+	for i := range rules {
+		if err := rules[i].validate(); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+// FaultInjector injects latency and failures into requests for chaos
+// testing. The first matching rule that fires applies. It does nothing
+// while disabled, and rules can be swapped at runtime.
+type FaultInjector struct {
+	mu      sync.RWMutex
+	enabled bool
+	rules   []FaultRule
+}
+
+func NewFaultInjector(enabled bool, rules ...FaultRule) *FaultInjector {
+	return &FaultInjector{enabled: enabled, rules: rules}
+}
+
+// SetEnabled turns injection on or off
+func (f *FaultInjector) SetEnabled(enabled bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.enabled = enabled
+}
+
+// SetRules validates and replaces the rules
+func (f *FaultInjector) SetRules(rules []FaultRule) error {
+	for i := range rules {
+		if err := rules[i].validate(); err != nil {
+			return err
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = append([]FaultRule(nil), rules...)
+	return nil
+}
+
+// FaultConfig is the injector's runtime configuration
+type FaultConfig struct {
+	Enabled bool        `json:"enabled"`
+	Rules   []FaultRule `json:"rules"`
+}
+
+// Config returns the current configuration
+func (f *FaultInjector) Config() FaultConfig {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return FaultConfig{Enabled: f.enabled, Rules: append([]FaultRule{}, f.rules...)}
+}
+
+// Middleware returns a Middleware injecting faults
+func (f *FaultInjector) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &faultRoundTripper{Proxied: next, Injector: f}
+	}
+}
+
+// WithFaultInjector installs f beneath logging, next to the network, so
+// retries, the circuit breaker and the limiter all see injected faults
+func (c *HTTPClient) WithFaultInjector(f *FaultInjector) *HTTPClient {
+	c.Logging.Proxied = f.Middleware()(c.Logging.Proxied)
+	return c
+}
+
+// AdminHandler serves the configuration: GET returns it, PUT replaces it
+// with a FaultConfig body and DELETE disables injection and clears the rules.
+// Every request must carry token as a bearer token; an empty token rejects all.
+func (f *FaultInjector) AdminHandler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := bearerToken(r)
+		if !ok || token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="faults"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var cfg FaultConfig
+			if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := f.SetRules(cfg.Rules); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			f.SetEnabled(cfg.Enabled)
+		case http.MethodDelete:
+			f.SetRules(nil)
+			f.SetEnabled(false)
+		default:
+			w.Header().Set("Allow", "GET, PUT, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(f.Config())
+	})
+}
+
+// pick returns the rule to apply to req, if any
+func (f *FaultInjector) pick(req *http.Request) (FaultRule, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if !f.enabled {
+		return FaultRule{}, false
+	}
+	for _, r := range f.rules {
+		if r.matches(req) && rand.Float64() < r.Probability {
+			return r, true
+		}
+	}
+	return FaultRule{}, false
+}
+
+type faultRoundTripper struct {
+	Proxied  http.RoundTripper
+	Injector *FaultInjector
+}
+
+func (frt *faultRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rule, ok := frt.Injector.pick(req)
+	if !ok {
+		return frt.Proxied.RoundTrip(req)
+	}
+	ctx := req.Context()
+	faultsInjected.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("server.address", req.URL.Host),
+		attribute.String("rule", rule.Name),
+		attribute.String("fault", rule.Fault),
+	))
+
+	delay := time.Duration(rule.LatencyMs) * time.Millisecond
+	if rule.JitterMs > 0 {
+		delay += rand.N(time.Duration(rule.JitterMs) * time.Millisecond)
+	}
+	if rule.Fault == FaultTimeout {
+		delay = maxInjectedTimeout
+	}
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	switch rule.Fault {
+	case FaultError:
+		return nil, fmt.Errorf("connection reset by peer (rule %q): %w", rule.Name, ErrInjectedFault)
+	case FaultTimeout:
+		return nil, fmt.Errorf("rule %q: %w: %w", rule.Name, ErrInjectedFault, os.ErrDeadlineExceeded)
+	case FaultStatus:
+		code := rule.StatusCode
+		if code == 0 {
+			code = http.StatusServiceUnavailable
+		}
+		body, _ := json.Marshal(map[string]string{"error": "injected fault", "rule": rule.Name})
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", code, http.StatusText(code)),
+			StatusCode:    code,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": {"application/json"}},
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	case FaultTruncate:
+		resp, err := frt.Proxied.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = &truncatedBody{ReadCloser: resp.Body, remaining: rule.TruncateAt}
+		return resp, nil
+	}
+	return frt.Proxied.RoundTrip(req)
+}
+
+// truncatedBody ends in io.ErrUnexpectedEOF after remaining bytes, like a
+// dropped connection
+type truncatedBody struct {
+	io.ReadCloser
+	remaining int
+}
+
+func (b *truncatedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if len(p) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= n
+	return n, err
+}
@@ -0,0 +1,40 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFaultAdminHandlerRequiresToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string // configured
+		header string
+		want   int
+	}{
+		{name: "valid token", token: "s3cret", header: "Bearer s3cret", want: http.StatusOK},
+		{name: "wrong token", token: "s3cret", header: "Bearer guess", want: http.StatusUnauthorized},
+		{name: "no token", token: "s3cret", want: http.StatusUnauthorized},
+		{name: "not a bearer token", token: "s3cret", header: "Basic s3cret", want: http.StatusUnauthorized},
+		{name: "no token configured", header: "Bearer ", want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFaultInjector(false)
+			req := httptest.NewRequest(http.MethodPut, "/admin/faults", strings.NewReader(`{"enabled":true,"rules":[]}`))
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			f.AdminHandler(tt.token).ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if enabled := f.Config().Enabled; enabled != (tt.want == http.StatusOK) {
+				t.Fatalf("enabled = %v after status %d", enabled, w.Code)
+			}
+		})
+	}
+}
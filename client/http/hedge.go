@@ -0,0 +1,216 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// HedgeSettings configures HedgingRoundTripper
+type HedgeSettings struct {
+	// Delay before a hedge is sent while too few latencies have been seen
+	// to estimate Percentile
+	Delay      time.Duration
+	Percentile float64       // latency percentile used as the hedge delay, e.g. 0.95
+	MinDelay   time.Duration // bounds for the adaptive delay
+	MaxDelay   time.Duration
+	MaxHedges  int // extra attempts per request
+}
+
+// DefaultHedgeSettings sends one hedge after the observed p95 latency
+func DefaultHedgeSettings() HedgeSettings {
+	return HedgeSettings{
+		Delay:      200 * time.Millisecond,
+		Percentile: 0.95,
+		MinDelay:   10 * time.Millisecond,
+		MaxDelay:   2 * time.Second,
+		MaxHedges:  1,
+	}
+}
+
+// minLatencySamples is how many latencies are needed before the delay adapts
+const minLatencySamples = 20
+
+// HedgingRoundTripper sends a GET or HEAD and, if it has not answered within
+// the hedge delay, sends it again. The first response wins and the other
+// attempts are cancelled; an error only wins when every attempt fails.
+type HedgingRoundTripper struct {
+	Proxied  http.RoundTripper
+	Settings HedgeSettings
+
+	latencies *latencyWindow
+}
+
+// WithHedging returns a Middleware hedging idempotent reads
+func WithHedging(settings HedgeSettings) Middleware {
+	if settings.MaxHedges < 1 {
+		settings.MaxHedges = 1
+	}
+	if settings.Percentile <= 0 || settings.Percentile >= 1 {
+		settings.Percentile = 0.95
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &HedgingRoundTripper{Proxied: next, Settings: settings, latencies: newLatencyWindow(200)}
+	}
+}
+
+type hedgeResult struct {
+	attempt int
+	resp    *http.Response
+	err     error
+	cancel  context.CancelFunc
+}
+
+func (hrt *HedgingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if (req.Method != http.MethodGet && req.Method != http.MethodHead) || (req.Body != nil && req.Body != http.NoBody) {
+		return hrt.Proxied.RoundTrip(req)
+	}
+	ctx := req.Context()
+	results := make(chan hedgeResult, hrt.Settings.MaxHedges+1)
+	start := time.Now()
+	var cancels []context.CancelFunc
+	launch := func(attempt int) {
+		attemptCtx, cancel := context.WithCancel(ctx)
+		cancels = append(cancels, cancel)
+		go func() {
+			resp, err := hrt.Proxied.RoundTrip(req.Clone(attemptCtx))
+			results <- hedgeResult{attempt: attempt, resp: resp, err: err, cancel: cancel}
+		}()
+	}
+
+	launch(0)
+	sent, pending := 1, 1
+	timer := time.NewTimer(hrt.delay())
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			if sent <= hrt.Settings.MaxHedges {
+				hedgesSent.Add(ctx, 1, metric.WithAttributes(attribute.String("server.address", req.URL.Host)))
+				launch(sent)
+				sent++
+				pending++
+				timer.Reset(hrt.delay())
+			}
+		case r := <-results:
+			pending--
+			if r.err != nil {
+				r.cancel()
+				if pending > 0 {
+					continue
+				}
+				return nil, r.err
+			}
+			hrt.latencies.add(time.Since(start))
+			winner := "primary"
+			if r.attempt > 0 {
+				winner = "hedge"
+			}
+			hedgeWins.Add(ctx, 1, metric.WithAttributes(
+				attribute.String("server.address", req.URL.Host),
+				attribute.String("winner", winner),
+			))
+			// Cancel the losers now rather than when they happen to answer
+			for i, cancel := range cancels {
+				if i != r.attempt {
+					cancel()
+				}
+			}
+			go discardHedges(results, pending)
+			r.resp.Body = &cancelOnClose{ReadCloser: r.resp.Body, cancel: r.cancel}
+			return r.resp, nil
+		case <-ctx.Done():
+			go discardHedges(results, pending)
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// discardHedges releases the n cancelled attempts still running and closes
+// their responses as they arrive
+func discardHedges(results <-chan hedgeResult, n int) {
+	for ; n > 0; n-- {
+		r := <-results
+		r.cancel()
+		if r.resp != nil {
+			r.resp.Body.Close()
+		}
+	}
+}
+
+// delay returns the current hedge delay
+func (hrt *HedgingRoundTripper) delay() time.Duration {
+	d, ok := hrt.latencies.percentile(hrt.Settings.Percentile)
+	if !ok {
+		d = hrt.Settings.Delay
+	}
+	if hrt.Settings.MinDelay > 0 && d < hrt.Settings.MinDelay {
+		d = hrt.Settings.MinDelay
+	}
+	if hrt.Settings.MaxDelay > 0 && d > hrt.Settings.MaxDelay {
+		d = hrt.Settings.MaxDelay
+	}
+	return d
+}
+
+// WithHedging installs request hedging as the outermost middleware, so each
+// hedge gets its own retries and circuit breaker check
+func (c *HTTPClient) WithHedging(settings HedgeSettings) *HTTPClient {
+	return c.Use(WithHedging(settings))
+}
+
+// cancelOnClose releases a winning attempt's context once its body is done
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// latencyWindow keeps the most recent latencies in a ring
+type latencyWindow struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+	full    bool
+}
+
+func newLatencyWindow(size int) *latencyWindow {
+	return &latencyWindow{samples: make([]time.Duration, size)}
+}
+
+func (w *latencyWindow) add(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % len(w.samples)
+	if w.next == 0 {
+		w.full = true
+	}
+}
+
+func (w *latencyWindow) percentile(p float64) (time.Duration, bool) {
+	w.mu.Lock()
+	n := w.next
+	if w.full {
+		n = len(w.samples)
+	}
+	if n < minLatencySamples {
+		w.mu.Unlock()
+		return 0, false
+	}
+	sorted := slices.Clone(w.samples[:n])
+	w.mu.Unlock()
+	slices.Sort(sorted)
+	return sorted[int(p*float64(n-1))], true
+}
@@ -0,0 +1,145 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowTransport answers attempt i after delays[i], or fails with errs[i],
+// and records whether each attempt's context was cancelled
+type slowTransport struct {
+	delays []time.Duration
+	errs   []error
+
+	mu        sync.Mutex
+	attempts  int
+	cancelled []chan struct{}
+}
+
+func (s *slowTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.mu.Lock()
+	i := s.attempts
+	s.attempts++
+	cancelled := make(chan struct{})
+	s.cancelled = append(s.cancelled, cancelled)
+	s.mu.Unlock()
+	go func() {
+		<-req.Context().Done()
+		close(cancelled)
+	}()
+
+	select {
+	case <-time.After(s.delays[i]):
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	if i < len(s.errs) && s.errs[i] != nil {
+		return nil, s.errs[i]
+	}
+	resp := response(req, http.StatusOK, nil)
+	resp.Header.Set("X-Attempt", strings.Repeat("h", i)+"p")
+	return resp, nil
+}
+
+func (s *slowTransport) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attempts
+}
+
+// waitCancelled fails unless attempt i's context is cancelled soon
+func (s *slowTransport) waitCancelled(t *testing.T, i int) {
+	t.Helper()
+	s.mu.Lock()
+	ch := s.cancelled[i]
+	s.mu.Unlock()
+	select {
+	case <-ch:
+	case <-time.After(200 * time.Millisecond):
+		t.Fatalf("attempt %d was not cancelled", i)
+	}
+}
+
+func hedging(next http.RoundTripper) http.RoundTripper {
+	return WithHedging(HedgeSettings{Delay: 20 * time.Millisecond, MaxHedges: 1})(next)
+}
+
+func TestHedgingRoundTripper(t *testing.T) {
+	boom := errors.New("connection reset")
+	tests := []struct {
+		name         string
+		method       string
+		delays       []time.Duration
+		errs         []error
+		wantAttempts int
+		wantWinner   string // X-Attempt of the response, "" for an error
+		wantLoser    int    // attempt that must be cancelled, -1 for none
+	}{
+		{name: "fast primary sends no hedge", method: "GET", delays: []time.Duration{0}, wantAttempts: 1, wantWinner: "p", wantLoser: -1},
+		{name: "hedge wins and the primary is cancelled", method: "GET", delays: []time.Duration{time.Second, 0}, wantAttempts: 2, wantWinner: "hp", wantLoser: 0},
+		{name: "primary wins and the hedge is cancelled", method: "GET", delays: []time.Duration{40 * time.Millisecond, time.Second}, wantAttempts: 2, wantWinner: "p", wantLoser: 1},
+		{name: "a failed attempt does not win", method: "GET", delays: []time.Duration{30 * time.Millisecond, 30 * time.Millisecond}, errs: []error{boom}, wantAttempts: 2, wantWinner: "hp", wantLoser: -1},
+		{name: "errors win only when every attempt fails", method: "GET", delays: []time.Duration{30 * time.Millisecond, 0}, errs: []error{boom, boom}, wantAttempts: 2, wantLoser: -1},
+		{name: "POST is not hedged", method: "POST", delays: []time.Duration{50 * time.Millisecond}, wantAttempts: 1, wantWinner: "p", wantLoser: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &slowTransport{delays: tt.delays, errs: tt.errs}
+			req, _ := http.NewRequest(tt.method, "http://members.example.com/members/1", nil)
+			resp, err := hedging(transport).RoundTrip(req)
+			if tt.wantWinner == "" {
+				if !errors.Is(err, boom) {
+					t.Fatalf("err = %v, want %v", err, boom)
+				}
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := resp.Header.Get("X-Attempt"); got != tt.wantWinner {
+					t.Fatalf("winner = %q, want %q", got, tt.wantWinner)
+				}
+				resp.Body.Close()
+			}
+			if transport.count() != tt.wantAttempts {
+				t.Fatalf("attempts = %d, want %d", transport.count(), tt.wantAttempts)
+			}
+			if tt.wantLoser >= 0 {
+				transport.waitCancelled(t, tt.wantLoser)
+			}
+		})
+	}
+}
+
+func TestHedgingRoundTripperCallerCancel(t *testing.T) {
+	transport := &slowTransport{delays: []time.Duration{time.Second, time.Second}}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", "http://members.example.com/members/1", nil)
+
+	if _, err := hedging(transport).RoundTrip(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+	transport.waitCancelled(t, 0)
+	transport.waitCancelled(t, 1)
+}
+
+func TestHedgingRoundTripperWinnerContextLivesUntilClose(t *testing.T) {
+	transport := &slowTransport{delays: []time.Duration{0}}
+	req, _ := http.NewRequest("GET", "http://members.example.com/members/1", nil)
+	resp, err := hedging(transport).RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-transport.cancelled[0]:
+		t.Fatal("winning attempt cancelled before its body was closed")
+	case <-time.After(20 * time.Millisecond):
+	}
+	resp.Body.Close()
+	transport.waitCancelled(t, 0)
+}
@@ -5,7 +5,6 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
-	"strings"
 	"time"
 )
 
@@ -14,69 +13,125 @@ import (
 type LoggingRoundTripper struct {
 	Proxied     http.RoundTripper
 	Logger      *slog.Logger
-	LogRequest  bool // flag to log requests
-	LogResponse bool // flag to log responses
+	LogRequest  bool      // flag to log requests
+	LogResponse bool      // flag to log responses
+	Redactor    *Redactor // masks headers, URLs and bodies; DefaultRedactor when nil
 }
 
+// maxLoggedBody limits how much of a body is logged
+const maxLoggedBody = 2048
+
 func (lrt *LoggingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 	start := time.Now()
+	redactor := lrt.Redactor
+	if redactor == nil {
+		redactor = DefaultRedactor()
+	}
+	url := redactor.URL(req.URL)
 
 	// Log request method, url, headers
 	if lrt.LogRequest {
-		lrt.Logger.Info("HTTP request", "method", req.Method, "url", req.URL.String())
+		lrt.Logger.Info("HTTP request", "method", req.Method, "url", url, "headers", redactor.Headers(req.Header))
 	}
 
-	// Log request body (if present and small)
-	if lrt.LogRequest && req.Body != nil && req.ContentLength != 0 {
-		var bodyBytes []byte
-		bodyBytes, _ = io.ReadAll(io.LimitReader(req.Body, 2048)) // limit to 2KB
-		_ = req.Body.Close()
-		req.Body = io.NopCloser(strings.NewReader(string(bodyBytes))) // restore body
-		if len(bodyBytes) > 0 {
-			lrt.Logger.Info("HTTP request body", "body", string(bodyBytes))
+	// Log request body (if present and textual)
+	if lrt.LogRequest && req.Body != nil && req.ContentLength != 0 && redactor.LogBody(req.Header.Get("Content-Type")) {
+		var preview []byte
+		preview, req.Body = peekBody(req.Body)
+		if len(preview) > 0 {
+			lrt.Logger.Info("HTTP request body", "body", redactor.Body(preview))
 		}
 	}
 
 	resp, err := lrt.Proxied.RoundTrip(req)
 	duration := time.Since(start)
 	if err != nil {
-		lrt.Logger.Error("HTTP error", "method", req.Method, "url", req.URL.String(), "err", err, "duration", duration)
+		lrt.Logger.Error("HTTP error", "method", req.Method, "url", url, "err", err, "duration", duration)
 		return nil, err
 	}
 
 	// Log response status, headers
 	if lrt.LogResponse {
-		lrt.Logger.Info("HTTP response", "method", req.Method, "url", req.URL.String(), "status", resp.StatusCode, "duration", duration)
+		lrt.Logger.Info("HTTP response", "method", req.Method, "url", url, "status", resp.StatusCode,
+			"headers", redactor.Headers(resp.Header), "duration", duration)
 	}
 
-	// Log response body (if present and small)
-	if lrt.LogResponse && resp.Body != nil {
-		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 2048)) // limit to 2KB
-		resp.Body.Close()
-		resp.Body = io.NopCloser(bytes.NewReader(bodyBytes)) // restore body correctly
-		if len(bodyBytes) > 0 {
-			lrt.Logger.Info("HTTP response body", "body", string(bodyBytes))
+	// Log response body (if present and textual)
+	if lrt.LogResponse && resp.Body != nil && redactor.LogBody(resp.Header.Get("Content-Type")) {
+		var preview []byte
+		preview, resp.Body = peekBody(resp.Body)
+		if len(preview) > 0 {
+			lrt.Logger.Info("HTTP response body", "body", redactor.Body(preview))
 		}
 	}
 
 	return resp, nil
 }
 
+// peekBody reads up to maxLoggedBody bytes and returns them along with a
+// body that still yields the full, unconsumed content
+func peekBody(body io.ReadCloser) ([]byte, io.ReadCloser) {
+	preview, _ := io.ReadAll(io.LimitReader(body, maxLoggedBody))
+	return preview, struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(preview), body), body}
+}
+
+// Middleware wraps a RoundTripper with additional behaviour
+type Middleware func(http.RoundTripper) http.RoundTripper
+
 // HTTPClient is a base client for making HTTP requests
 type HTTPClient struct {
-	Client  *http.Client
-	BaseURL string
+	Client         *http.Client
+	BaseURL        string
+	CircuitBreaker *CircuitBreaker      // set when the client fails fast on unhealthy hosts
+	Limiter        *Limiter             // set when requests are rate limited client-side
+	Logging        *LoggingRoundTripper // innermost transport, for adjusting logging after construction
+	DefaultHeaders http.Header          // added to every request made through the JSON helpers
+	StrictDecoding bool                 // reject unknown response fields in the JSON helpers
+
+	base *baseTransport // bottom of the chain beneath logging, swapped by WithTransport
 }
 
 func NewHTTPClient(baseURL string, logger *slog.Logger, logRequest, logResponse bool) *HTTPClient {
 	if logger == nil {
 		logger = slog.Default()
 	}
+	base := &baseTransport{RoundTripper: http.DefaultTransport}
+	logging := &LoggingRoundTripper{Proxied: base, Logger: logger, LogRequest: logRequest, LogResponse: logResponse}
 	return &HTTPClient{
 		Client: &http.Client{
 			Timeout:   30 * time.Second,
-			Transport: &LoggingRoundTripper{Proxied: http.DefaultTransport, Logger: logger, LogRequest: logRequest, LogResponse: logResponse},
+			Transport: logging,
 		},
-		BaseURL: baseURL,
+		BaseURL:        baseURL,
+		Logging:        logging,
+		DefaultHeaders: http.Header{"User-Agent": {"azure-client-go"}},
+		base:           base,
+	}
+}
+
+// WithCircuitBreaker installs cb as the outermost middleware so open circuits
+// fail fast before any retries
+func (c *HTTPClient) WithCircuitBreaker(cb *CircuitBreaker) *HTTPClient {
+	c.CircuitBreaker = cb
+	return c.Use(cb.Middleware())
+}
+
+// Use wraps the client's transport with the given middlewares. Each call
+// wraps the current chain, so the last middleware added runs first.
+func (c *HTTPClient) Use(mws ...Middleware) *HTTPClient {
+	for _, mw := range mws {
+		c.Client.Transport = mw(c.Client.Transport)
+	}
+	return c
+}
+
+// redactor returns the logging Redactor, which also cleans HTTPErrors
+func (c *HTTPClient) redactor() *Redactor {
+	if c.Logging != nil && c.Logging.Redactor != nil {
+		return c.Logging.Redactor
 	}
+	return DefaultRedactor()
 }
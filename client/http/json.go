@@ -0,0 +1,312 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxErrorSnippet limits how much of an error response body HTTPError keeps
+const maxErrorSnippet = 1024
+
+// HTTPError is returned by the JSON helpers for non-2xx responses. Errors end
+// up in logs and traces, so URL and Body are redacted like logged requests.
+type HTTPError struct {
+	Method     string
+	URL        string // query parameters masked, credentials dropped
+	StatusCode int
+	Status     string
+	Header     http.Header
+	Body       []byte // first maxErrorSnippet bytes of the response body, redacted; nil when not textual
+}
+
+func (e *HTTPError) Error() string {
+	msg := fmt.Sprintf("%s %s: %s", e.Method, e.URL, e.Status)
+	if len(e.Body) > 0 {
+		msg += ": " + strings.TrimSpace(string(e.Body))
+	}
+	return msg
+}
+
+// Decode unmarshals the error body into v, for APIs with structured errors
+func (e *HTTPError) Decode(v any) error {
+	return json.Unmarshal(e.Body, v)
+}
+
+// IsStatus reports whether err is an *HTTPError with the given status code
+func IsStatus(err error, code int) bool {
+	var httpErr *HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == code
+}
+
+type requestOptions struct {
+	query      url.Values
+	header     http.Header
+	strict     bool
+	respHeader *http.Header
+}
+
+// RequestOption customises a single JSON helper call
+type RequestOption func(*requestOptions) error
+
+// WithQuery adds query parameters from url.Values, a map[string]string or a
+// struct whose fields carry `url:"name,omitempty"` tags
+func WithQuery(params any) RequestOption {
+	return func(o *requestOptions) error {
+		return encodeQuery(o.query, params)
+	}
+}
+
+// WithHeader sets a request header
+func WithHeader(key, value string) RequestOption {
+	return func(o *requestOptions) error {
+		o.header.Set(key, value)
+		return nil
+	}
+}
+
+// WithResponseHeader stores the response headers of a successful call in dst
+func WithResponseHeader(dst *http.Header) RequestOption {
+	return func(o *requestOptions) error {
+		o.respHeader = dst
+		return nil
+	}
+}
+
+// WithStrictDecoding rejects response fields that Resp does not declare
+func WithStrictDecoding() RequestOption {
+	return func(o *requestOptions) error {
+		o.strict = true
+		return nil
+	}
+}
+
+// Do sends body as JSON to path (relative to BaseURL) and decodes a JSON
+// response into Resp. A nil body sends no content; an empty or 204 response
+// yields the zero Resp. Non-2xx responses return an *HTTPError.
+func Do[Req, Resp any](ctx context.Context, c *HTTPClient, method, path string, body Req, opts ...RequestOption) (Resp, error) {
+	var out Resp
+	o := requestOptions{query: url.Values{}, header: http.Header{}, strict: c.StrictDecoding}
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return out, err
+		}
+	}
+
+	var reader io.Reader
+	if !isNil(body) {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return out, fmt.Errorf("failed to encode request body: %w", err)
+		}
+		reader = bytes.NewReader(b)
+	}
+	u := c.BaseURL + path
+	if len(o.query) > 0 {
+		sep := "?"
+		if strings.Contains(u, "?") {
+			sep = "&"
+		}
+		u += sep + o.query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return out, err
+	}
+	for k, v := range c.DefaultHeaders {
+		req.Header[k] = append([]string(nil), v...)
+	}
+	req.Header.Set("Accept", "application/json")
+	if reader != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range o.header {
+		req.Header[k] = v
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return out, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		redactor := c.redactor()
+		var snippet []byte
+		if redactor.LogBody(resp.Header.Get("Content-Type")) {
+			b, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorSnippet))
+			if len(b) > 0 {
+				snippet = []byte(redactor.Body(b))
+			}
+		}
+		return out, &HTTPError{
+			Method:     method,
+			URL:        redactor.URL(req.URL),
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Header:     resp.Header,
+			Body:       snippet,
+		}
+	}
+	if o.respHeader != nil {
+		*o.respHeader = resp.Header
+	}
+	if resp.StatusCode == http.StatusNoContent {
+		return out, nil
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return out, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return out, nil
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" && !isJSONContentType(ct) {
+		return out, fmt.Errorf("unexpected response content type %q", ct)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if o.strict {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(&out); err != nil {
+		return out, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if o.strict && dec.More() {
+		return out, errors.New("failed to decode response: unexpected data after JSON value")
+	}
+	return out, nil
+}
+
+// Get is Do for a GET without a body
+func Get[Resp any](ctx context.Context, c *HTTPClient, path string, opts ...RequestOption) (Resp, error) {
+	return Do[any, Resp](ctx, c, http.MethodGet, path, nil, opts...)
+}
+
+// Post is Do for a POST
+func Post[Req, Resp any](ctx context.Context, c *HTTPClient, path string, body Req, opts ...RequestOption) (Resp, error) {
+	return Do[Req, Resp](ctx, c, http.MethodPost, path, body, opts...)
+}
+
+// Put is Do for a PUT
+func Put[Req, Resp any](ctx context.Context, c *HTTPClient, path string, body Req, opts ...RequestOption) (Resp, error) {
+	return Do[Req, Resp](ctx, c, http.MethodPut, path, body, opts...)
+}
+
+// Delete is Do for a DELETE without a body, discarding any response
+func Delete(ctx context.Context, c *HTTPClient, path string, opts ...RequestOption) error {
+	_, err := Do[any, json.RawMessage](ctx, c, http.MethodDelete, path, nil, opts...)
+	return err
+}
+
+func isJSONContentType(ct string) bool {
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// PathEscape escapes a value for use as a single path segment
+func PathEscape(v any) string {
+	return url.PathEscape(fmt.Sprint(v))
+}
+
+func encodeQuery(q url.Values, params any) error {
+	switch p := params.(type) {
+	case nil:
+		return nil
+	case url.Values:
+		for k, v := range p {
+			q[k] = append(q[k], v...)
+		}
+		return nil
+	case map[string]string:
+		for k, v := range p {
+			q.Add(k, v)
+		}
+		return nil
+	}
+	rv := reflect.ValueOf(params)
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("unsupported query parameter type %T", params)
+	}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("url"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fv := rv.Field(i)
+		if opts == "omitempty" && fv.IsZero() {
+			continue
+		}
+		if fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Slice {
+			for j := 0; j < fv.Len(); j++ {
+				q.Add(name, formatQueryValue(fv.Index(j)))
+			}
+			continue
+		}
+		q.Add(name, formatQueryValue(fv))
+	}
+	return nil
+}
+
+func formatQueryValue(v reflect.Value) string {
+	switch x := v.Interface().(type) {
+	case time.Time:
+		return x.Format(time.RFC3339)
+	case fmt.Stringer:
+		return x.String()
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,249 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// echoed is what echoServer reports about the request it received
+type echoed struct {
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Query       string `json:"query"`
+	ContentType string `json:"content_type"`
+	Accept      string `json:"accept"`
+	Body        string `json:"body"`
+}
+
+func echoServer(t *testing.T) *HTTPClient {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(echoed{
+			Method:      r.Method,
+			Path:        r.URL.Path,
+			Query:       r.URL.RawQuery,
+			ContentType: r.Header.Get("Content-Type"),
+			Accept:      r.Header.Get("Accept"),
+			Body:        string(body),
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return NewHTTPClient(srv.URL, nil, false, false)
+}
+
+func TestJSONHelpers(t *testing.T) {
+	c := echoServer(t)
+	ctx := context.Background()
+	type item struct {
+		Name string `json:"name"`
+	}
+	tests := []struct {
+		name string
+		call func() (echoed, error)
+		want echoed
+	}{
+		{
+			name: "get with query",
+			call: func() (echoed, error) {
+				return Get[echoed](ctx, c, "/items", WithQuery(map[string]string{"limit": "10"}))
+			},
+			want: echoed{Method: "GET", Path: "/items", Query: "limit=10", Accept: "application/json"},
+		},
+		{
+			name: "post sends json",
+			call: func() (echoed, error) { return Post[item, echoed](ctx, c, "/items", item{Name: "a"}) },
+			want: echoed{Method: "POST", Path: "/items", ContentType: "application/json", Accept: "application/json", Body: `{"name":"a"}`},
+		},
+		{
+			name: "put sends json",
+			call: func() (echoed, error) { return Put[item, echoed](ctx, c, "/items/1", item{Name: "b"}) },
+			want: echoed{Method: "PUT", Path: "/items/1", ContentType: "application/json", Accept: "application/json", Body: `{"name":"b"}`},
+		},
+		{
+			name: "nil body sends no content",
+			call: func() (echoed, error) { return Do[*item, echoed](ctx, c, "PATCH", "/items/1", nil) },
+			want: echoed{Method: "PATCH", Path: "/items/1", Accept: "application/json"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.call()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got.Body = strings.TrimSpace(got.Body)
+			if got != tt.want {
+				t.Errorf("request = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+	if err := Delete(ctx, c, "/items/1"); err != nil {
+		t.Errorf("Delete() = %v", err)
+	}
+}
+
+func TestJSONHelperResponses(t *testing.T) {
+	type member struct {
+		Name string `json:"name"`
+	}
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		opts        []RequestOption
+		want        member
+		wantErr     string
+	}{
+		{name: "decodes json", status: 200, contentType: "application/json", body: `{"name":"Ada","extra":1}`, want: member{Name: "Ada"}},
+		{name: "no content", status: 204},
+		{name: "empty body", status: 200, contentType: "application/json"},
+		{name: "wrong content type", status: 200, contentType: "text/html", body: "<html>", wantErr: `unexpected response content type "text/html"`},
+		{name: "malformed json", status: 200, contentType: "application/json", body: `{"name":`, wantErr: "failed to decode response"},
+		{
+			name: "strict rejects unknown fields", status: 200, contentType: "application/json", body: `{"name":"Ada","extra":1}`,
+			opts: []RequestOption{WithStrictDecoding()}, wantErr: `unknown field "extra"`,
+		},
+		{
+			name: "strict rejects trailing data", status: 200, contentType: "application/json", body: `{"name":"Ada"} {}`,
+			opts: []RequestOption{WithStrictDecoding()}, wantErr: "unexpected data after JSON value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+			got, err := Get[member](context.Background(), NewHTTPClient(srv.URL, nil, false, false), "/members/1", tt.opts...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPErrorIsRedacted(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantBody    string
+	}{
+		{
+			name:        "json body",
+			contentType: "application/json",
+			body:        `{"error":"no member ada@example.com","token":"abc"}`,
+			wantBody:    `{"error":"no member [REDACTED]","token":"[REDACTED]"}`,
+		},
+		{name: "binary body is dropped", contentType: "application/octet-stream", body: "\x00\x01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(http.StatusNotFound)
+				io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+			c := NewHTTPClient(srv.URL, nil, false, false)
+			_, err := Get[json.RawMessage](context.Background(), c, "/members", WithQuery(url.Values{"token": {"s3cret"}}))
+
+			if !IsStatus(err, http.StatusNotFound) {
+				t.Fatalf("err = %v, want a 404 HTTPError", err)
+			}
+			httpErr := err.(*HTTPError)
+			if want := srv.URL + "/members?token=%5BREDACTED%5D"; httpErr.URL != want {
+				t.Errorf("URL = %q, want %q", httpErr.URL, want)
+			}
+			if string(httpErr.Body) != tt.wantBody {
+				t.Errorf("Body = %q, want %q", httpErr.Body, tt.wantBody)
+			}
+			if strings.Contains(err.Error(), "s3cret") || strings.Contains(err.Error(), "ada@example.com") {
+				t.Errorf("Error() leaks sensitive data: %s", err)
+			}
+			if tt.wantBody != "" {
+				var body struct{ Error string }
+				if err := httpErr.Decode(&body); err != nil || body.Error != "no member [REDACTED]" {
+					t.Errorf("Decode() = %+v, %v", body, err)
+				}
+			}
+		})
+	}
+}
+
+type stringer string
+
+func (s stringer) String() string { return "s:" + string(s) }
+
+func TestEncodeQuery(t *testing.T) {
+	limit := 5
+	tests := []struct {
+		name    string
+		params  any
+		want    string
+		wantErr bool
+	}{
+		{name: "nil", params: nil, want: ""},
+		{name: "url values", params: url.Values{"a": {"1", "2"}}, want: "a=1&a=2"},
+		{name: "map", params: map[string]string{"b": "x y"}, want: "b=x+y"},
+		{
+			name: "struct tags",
+			params: struct {
+				Status  string    `url:"status,omitempty"`
+				Cursor  string    `url:"cursor,omitempty"`
+				Limit   *int      `url:"limit"`
+				Offset  *int      `url:"offset"`
+				Tags    []string  `url:"tag"`
+				Active  bool      `url:"active"`
+				Since   time.Time `url:"since"`
+				Kind    stringer  `url:"kind"`
+				Ratio   float64   `url:"ratio"`
+				Skipped string    `url:"-"`
+				Plain   uint
+				private string
+			}{
+				Status: "paid", Limit: &limit, Tags: []string{"a", "b"}, Active: true,
+				Since: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), Kind: "k", Ratio: 0.5,
+				Skipped: "x", Plain: 7, private: "p",
+			},
+			want: "Plain=7&active=true&kind=s%3Ak&limit=5&ratio=0.5&since=2026-01-02T03%3A04%3A05Z&status=paid&tag=a&tag=b",
+		},
+		{name: "nil struct pointer", params: (*struct{ A string })(nil), want: ""},
+		{name: "unsupported type", params: 42, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := url.Values{}
+			err := encodeQuery(q, tt.params)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := q.Encode(); got != tt.want {
+				t.Errorf("query = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
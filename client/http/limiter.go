@@ -0,0 +1,252 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"azureclient/internal/ratelimit"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+var (
+	// ErrRateLimited is returned when the client-side rate limit rejects a
+	// request, either failing fast or because the wait would outlast the
+	// context deadline
+	ErrRateLimited = errors.New("client rate limit exceeded")
+	// ErrBulkheadFull is returned when too many requests are already in flight
+	ErrBulkheadFull = errors.New("too many requests in flight")
+)
+
+// LimiterSettings configures Limiter
+type LimiterSettings struct {
+	RatePerSecond float64 // steady request rate, 0 for no rate limit
+	Burst         int     // requests allowed back to back
+	MaxInFlight   int     // concurrent requests, 0 for no bulkhead
+	FailFast      bool    // reject instead of waiting for a token or slot
+	// Adaptive backoff: each 429 multiplies the rate by BackoffFactor, down
+	// to MinRatePerSecond, and pauses the client for any Retry-After. The
+	// rate climbs back by a tenth of RatePerSecond every RecoveryInterval
+	// without a 429.
+	BackoffFactor    float64
+	MinRatePerSecond float64
+	RecoveryInterval time.Duration
+}
+
+// DefaultLimiterSettings limits to rps requests per second with a burst of
+// a tenth of that and halves the rate on 429s
+func DefaultLimiterSettings(rps float64, maxInFlight int) LimiterSettings {
+	return LimiterSettings{
+		RatePerSecond:    rps,
+		Burst:            max(int(rps/10), 1),
+		MaxInFlight:      maxInFlight,
+		BackoffFactor:    0.5,
+		MinRatePerSecond: rps / 10,
+		RecoveryInterval: 5 * time.Second,
+	}
+}
+
+// Limiter is a token-bucket rate limiter combined with a max-in-flight
+// bulkhead for one downstream service
+type Limiter struct {
+	settings LimiterSettings
+	bucket   *ratelimit.TokenBucket
+	slots    chan struct{}
+
+	mu          sync.Mutex
+	pausedUntil time.Time
+	lastAdjust  time.Time
+}
+
+func NewLimiter(settings LimiterSettings) *Limiter {
+	if settings.BackoffFactor <= 0 || settings.BackoffFactor >= 1 {
+		settings.BackoffFactor = 0.5
+	}
+	if settings.RecoveryInterval <= 0 {
+		settings.RecoveryInterval = 5 * time.Second
+	}
+	l := &Limiter{
+		settings:   settings,
+		bucket:     ratelimit.NewTokenBucket(settings.RatePerSecond, settings.Burst),
+		lastAdjust: time.Now(),
+	}
+	if settings.MaxInFlight > 0 {
+		l.slots = make(chan struct{}, settings.MaxInFlight)
+	}
+	return l
+}
+
+// Middleware returns a Middleware enforcing the limiter
+func (l *Limiter) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &limiterRoundTripper{Proxied: next, Limiter: l}
+	}
+}
+
+// Rate returns the current, possibly backed off, rate in requests per second
+func (l *Limiter) Rate() float64 {
+	return l.bucket.Rate()
+}
+
+// InFlight returns the number of requests currently holding a bulkhead slot
+func (l *Limiter) InFlight() int {
+	return len(l.slots)
+}
+
+// acquire waits for a rate token and a bulkhead slot, returning the
+// function that frees the slot
+func (l *Limiter) acquire(ctx context.Context) (func(), error) {
+	if err := l.waitPause(ctx); err != nil {
+		return nil, err
+	}
+	if l.settings.FailFast {
+		return l.tryAcquire()
+	}
+	if err := l.bucket.Wait(ctx); err != nil {
+		if errors.Is(err, ratelimit.ErrWouldExceedDeadline) {
+			return nil, fmt.Errorf("%w: %w", ErrRateLimited, err)
+		}
+		return nil, err
+	}
+
+	if l.slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return func() { <-l.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// tryAcquire is acquire without waiting. The bulkhead slot is taken before
+// the rate token, which cannot be given back, so a request rejected by the
+// bulkhead does not spend one.
+func (l *Limiter) tryAcquire() (func(), error) {
+	release := func() {}
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+			release = func() { <-l.slots }
+		default:
+			return nil, ErrBulkheadFull
+		}
+	}
+	if !l.bucket.Allow() {
+		release()
+		return nil, ErrRateLimited
+	}
+	return release, nil
+}
+
+// waitPause holds requests back while a Retry-After from a 429 is in force
+func (l *Limiter) waitPause(ctx context.Context) error {
+	l.mu.Lock()
+	delay := time.Until(l.pausedUntil)
+	l.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	if l.settings.FailFast {
+		return ErrRateLimited
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+		return fmt.Errorf("%w: %w", ErrRateLimited, ratelimit.ErrWouldExceedDeadline)
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// observe adapts the rate to the downstream's responses
+func (l *Limiter) observe(ctx context.Context, host string, resp *http.Response) {
+	if l.settings.RatePerSecond <= 0 {
+		return
+	}
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	rate := l.bucket.Rate()
+	if resp.StatusCode == http.StatusTooManyRequests {
+		throttled.Add(ctx, 1, metric.WithAttributes(attribute.String("server.address", host)))
+		if d, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok && now.Add(d).After(l.pausedUntil) {
+			l.pausedUntil = now.Add(d)
+		}
+		l.bucket.SetRate(max(rate*l.settings.BackoffFactor, l.settings.MinRatePerSecond))
+		l.lastAdjust = now
+		return
+	}
+	if rate < l.settings.RatePerSecond && now.Sub(l.lastAdjust) >= l.settings.RecoveryInterval {
+		l.bucket.SetRate(min(rate+l.settings.RatePerSecond/10, l.settings.RatePerSecond))
+		l.lastAdjust = now
+	}
+}
+
+// isLocalRejection reports whether err was raised by the client's own
+// limits rather than by the downstream
+func isLocalRejection(err error) bool {
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrBulkheadFull)
+}
+
+type limiterRoundTripper struct {
+	Proxied http.RoundTripper
+	Limiter *Limiter
+}
+
+func (lrt *limiterRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	release, err := lrt.Limiter.acquire(req.Context())
+	if err != nil {
+		reason := "rate"
+		if errors.Is(err, ErrBulkheadFull) {
+			reason = "bulkhead"
+		}
+		if isLocalRejection(err) {
+			limiterRejections.Add(req.Context(), 1, metric.WithAttributes(
+				attribute.String("server.address", req.URL.Host),
+				attribute.String("reason", reason),
+			))
+		}
+		return nil, err
+	}
+	resp, err := lrt.Proxied.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	lrt.Limiter.observe(req.Context(), req.URL.Host, resp)
+	// The request stays in flight until its body is closed
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// WithLimiter installs l beneath logging, so every attempt including
+// retries is paced and rejections are logged
+func (c *HTTPClient) WithLimiter(l *Limiter) *HTTPClient {
+	c.Limiter = l
+	c.Logging.Proxied = l.Middleware()(c.Logging.Proxied)
+	return c
+}
@@ -0,0 +1,196 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"azureclient/internal/ratelimit"
+)
+
+// limiterDo sends one request through rt and returns the response, if any
+func limiterDo(ctx context.Context, rt http.RoundTripper) (*http.Response, error) {
+	req, _ := http.NewRequestWithContext(ctx, "GET", "http://payments.example.com/x", nil)
+	return rt.RoundTrip(req)
+}
+
+func okResponse(req *http.Request) (*http.Response, error) {
+	return response(req, http.StatusOK, nil), nil
+}
+
+func TestLimiterTokenBucket(t *testing.T) {
+	tests := []struct {
+		name     string
+		failFast bool
+		timeout  time.Duration // context timeout for the second request, 0 for none
+		wantErr  error
+		minWait  time.Duration
+	}{
+		{name: "waits for a token", minWait: 50 * time.Millisecond},
+		{name: "fails fast", failFast: true, wantErr: ErrRateLimited},
+		{name: "wait would outlast the deadline", timeout: 10 * time.Millisecond, wantErr: ratelimit.ErrWouldExceedDeadline},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLimiter(LimiterSettings{RatePerSecond: 10, Burst: 1, FailFast: tt.failFast})
+			rt := l.Middleware()(roundTripFunc(okResponse))
+			if _, err := limiterDo(context.Background(), rt); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			start := time.Now()
+			_, err := limiterDo(ctx, rt)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) || !errors.Is(err, ErrRateLimited) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if waited := time.Since(start); waited < tt.minWait {
+				t.Errorf("waited %v, want at least %v", waited, tt.minWait)
+			}
+		})
+	}
+}
+
+func TestLimiterBulkheadReleasesOnBodyClose(t *testing.T) {
+	// A near-zero rate leaves only the burst, so a wasted token would show
+	l := NewLimiter(LimiterSettings{RatePerSecond: 0.001, Burst: 2, MaxInFlight: 1, FailFast: true})
+	rt := l.Middleware()(roundTripFunc(okResponse))
+
+	first, err := limiterDo(context.Background(), rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.InFlight() != 1 {
+		t.Fatalf("InFlight() = %d with an open body, want 1", l.InFlight())
+	}
+	if _, err := limiterDo(context.Background(), rt); !errors.Is(err, ErrBulkheadFull) {
+		t.Fatalf("err = %v, want %v", err, ErrBulkheadFull)
+	}
+	first.Body.Close()
+	first.Body.Close()
+	if l.InFlight() != 0 {
+		t.Fatalf("InFlight() = %d after Close, want 0", l.InFlight())
+	}
+	second, err := limiterDo(context.Background(), rt)
+	if err != nil {
+		t.Fatalf("request after release: %v (bulkhead rejection spent a token?)", err)
+	}
+	second.Body.Close()
+	if _, err := limiterDo(context.Background(), rt); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("err = %v, want %v once the burst is spent", err, ErrRateLimited)
+	}
+	if l.InFlight() != 0 {
+		t.Fatalf("InFlight() = %d after a rate rejection, want 0", l.InFlight())
+	}
+}
+
+func TestLimiterBackoffAndRecovery(t *testing.T) {
+	l := NewLimiter(LimiterSettings{
+		RatePerSecond:    100,
+		Burst:            100,
+		BackoffFactor:    0.5,
+		MinRatePerSecond: 20,
+		RecoveryInterval: time.Hour,
+	})
+	status := http.StatusTooManyRequests
+	rt := l.Middleware()(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return response(req, status, nil), nil
+	}))
+	send := func() {
+		t.Helper()
+		resp, err := limiterDo(context.Background(), rt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	for _, want := range []float64{50, 25, 20, 20} {
+		send()
+		if got := l.Rate(); got != want {
+			t.Fatalf("Rate() after 429 = %v, want %v", got, want)
+		}
+	}
+
+	status = http.StatusOK
+	send()
+	if got := l.Rate(); got != 20 {
+		t.Fatalf("Rate() within the recovery interval = %v, want 20", got)
+	}
+	for _, want := range []float64{30, 40, 50, 60, 70, 80, 90, 100, 100} {
+		l.mu.Lock()
+		l.lastAdjust = time.Now().Add(-time.Hour)
+		l.mu.Unlock()
+		send()
+		if got := l.Rate(); got != want {
+			t.Fatalf("Rate() after recovery = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestLimiterRetryAfterPause(t *testing.T) {
+	tests := []struct {
+		name     string
+		failFast bool
+		timeout  time.Duration
+		pause    time.Duration
+		wantErr  bool
+	}{
+		{name: "fails fast while paused", failFast: true, pause: time.Minute, wantErr: true},
+		{name: "pause would outlast the deadline", timeout: 10 * time.Millisecond, pause: time.Minute, wantErr: true},
+		{name: "waits out the pause", pause: 30 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLimiter(LimiterSettings{RatePerSecond: 100, Burst: 100, FailFast: tt.failFast})
+			rt := l.Middleware()(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				return response(req, http.StatusTooManyRequests, http.Header{"Retry-After": {"60"}}), nil
+			}))
+			resp, err := limiterDo(context.Background(), rt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			l.mu.Lock()
+			if until := time.Until(l.pausedUntil); until < 59*time.Second {
+				t.Fatalf("paused for %v after Retry-After: 60", until)
+			}
+			l.pausedUntil = time.Now().Add(tt.pause)
+			l.mu.Unlock()
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			start := time.Now()
+			_, err = l.acquire(ctx)
+			if tt.wantErr {
+				if !errors.Is(err, ErrRateLimited) {
+					t.Fatalf("err = %v, want %v", err, ErrRateLimited)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if waited := time.Since(start); waited < tt.pause/2 {
+				t.Errorf("waited %v, want about %v", waited, tt.pause)
+			}
+		})
+	}
+}
@@ -5,10 +5,23 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
+
+	"azureclient/internal/model"
 )
 
+// memberNextCursorHeader carries the cursor for the next page of /members
+const memberNextCursorHeader = "X-Next-Cursor"
+
+// DefaultMemberPageSize is used by MemberIterator when no page size is given
+const DefaultMemberPageSize = 100
+
 type MemberClientInterface interface {
-	DoSomething(ctx context.Context) error
+	GetMember(ctx context.Context, id uint) (*model.Member, error)
+	ListMembers(ctx context.Context, req ListMembersRequest) (*MemberPage, error)
+	CreateMember(ctx context.Context, member model.Member) (*model.Member, error)
+	UpdateMember(ctx context.Context, member model.Member) (*model.Member, error)
+	DeleteMember(ctx context.Context, id uint) error
 }
 
 type MemberClient struct {
@@ -16,22 +29,129 @@ type MemberClient struct {
 }
 
 func NewMemberClient(baseURL string) *MemberClient {
-	return &MemberClient{HTTPClient: NewHTTPClient(baseURL, slog.Default(), false, false)}
+	client := NewHTTPClient(baseURL, slog.Default(), false, false).
+		Use(WithTracing(), WithRetry(DefaultRetryPolicy())).
+		WithCircuitBreaker(NewCircuitBreaker(DefaultCircuitBreakerSettings()))
+	return &MemberClient{HTTPClient: client}
+}
+
+// ListMembersRequest selects one page of members
+type ListMembersRequest struct {
+	Limit int  `url:"limit,omitempty"`
+	After uint `url:"after,omitempty"` // cursor from the previous page's NextCursor
 }
 
-func (c *MemberClient) DoSomething(ctx context.Context) error {
-	url := c.BaseURL + "/member/endpoint"
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+// MemberPage is one page of members; NextCursor is 0 on the last page
+type MemberPage struct {
+	Members    []model.Member
+	NextCursor uint
+}
+
+func (c *MemberClient) GetMember(ctx context.Context, id uint) (*model.Member, error) {
+	member, err := Get[model.Member](ctx, c.HTTPClient, "/members/"+PathEscape(id))
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("get member: %w", err)
 	}
-	resp, err := c.Client.Do(req)
+	return &member, nil
+}
+
+// ListMembers returns one page of members; pass NextCursor back as After to
+// fetch the next page. A zero Limit asks the server for every member.
+func (c *MemberClient) ListMembers(ctx context.Context, req ListMembersRequest) (*MemberPage, error) {
+	var header http.Header
+	members, err := Get[[]model.Member](ctx, c.HTTPClient, "/members", WithQuery(req), WithResponseHeader(&header))
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("list members: %w", err)
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("member request failed: %s", resp.Status)
+	page := &MemberPage{Members: members}
+	if v := header.Get(memberNextCursorHeader); v != "" {
+		next, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("list members: invalid %s %q", memberNextCursorHeader, v)
+		}
+		page.NextCursor = uint(next)
+	}
+	return page, nil
+}
+
+func (c *MemberClient) CreateMember(ctx context.Context, member model.Member) (*model.Member, error) {
+	created, err := Post[model.Member, model.Member](ctx, c.HTTPClient, "/members", member)
+	if err != nil {
+		return nil, fmt.Errorf("create member: %w", err)
+	}
+	return &created, nil
+}
+
+// UpdateMember replaces the member identified by member.ID
+func (c *MemberClient) UpdateMember(ctx context.Context, member model.Member) (*model.Member, error) {
+	if member.ID == 0 {
+		return nil, fmt.Errorf("update member: missing ID")
+	}
+	updated, err := Put[model.Member, model.Member](ctx, c.HTTPClient, "/members/"+PathEscape(member.ID), member)
+	if err != nil {
+		return nil, fmt.Errorf("update member: %w", err)
+	}
+	return &updated, nil
+}
+
+func (c *MemberClient) DeleteMember(ctx context.Context, id uint) error {
+	if err := Delete(ctx, c.HTTPClient, "/members/"+PathEscape(id)); err != nil {
+		return fmt.Errorf("delete member: %w", err)
 	}
 	return nil
 }
+
+// Members returns an iterator over every member, fetched pageSize at a time
+func (c *MemberClient) Members(pageSize int) *MemberIterator {
+	if pageSize < 1 {
+		pageSize = DefaultMemberPageSize
+	}
+	return &MemberIterator{client: c, pageSize: pageSize}
+}
+
+// MemberIterator walks the paginated member list. Pages are fetched lazily:
+//
+//	it := client.Members(50)
+//	for it.Next(ctx) {
+//		m := it.Member()
+//	}
+//	if err := it.Err(); err != nil { ... }
+type MemberIterator struct {
+	client   *MemberClient
+	pageSize int
+	cursor   uint
+	page     []model.Member
+	current  model.Member
+	done     bool
+	err      error
+}
+
+// Next advances to the next member, fetching a new page when needed. It
+// returns false when the list is exhausted or a request fails.
+func (it *MemberIterator) Next(ctx context.Context) bool {
+	for len(it.page) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+		page, err := it.client.ListMembers(ctx, ListMembersRequest{Limit: it.pageSize, After: it.cursor})
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.page = page.Members
+		it.cursor = page.NextCursor
+		it.done = page.NextCursor == 0
+	}
+	it.current, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Member returns the member Next advanced to
+func (it *MemberIterator) Member() model.Member {
+	return it.current
+}
+
+// Err returns the error that stopped iteration, if any
+func (it *MemberIterator) Err() error {
+	return it.err
+}
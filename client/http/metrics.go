@@ -0,0 +1,31 @@
+package http
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// meter reports client metrics through the global MeterProvider; it is a
+// no-op until one is installed
+var meter = otel.Meter("azure-client-go/client/http")
+
+var (
+	circuitTransitions, _ = meter.Int64Counter("http.client.circuit_breaker.transitions",
+		metric.WithDescription("Circuit breaker state changes"))
+	circuitRejections, _ = meter.Int64Counter("http.client.circuit_breaker.rejections",
+		metric.WithDescription("Requests rejected while the circuit was open"))
+	limiterRejections, _ = meter.Int64Counter("http.client.limiter.rejections",
+		metric.WithDescription("Requests rejected by the client-side rate limit or bulkhead"))
+	throttled, _ = meter.Int64Counter("http.client.limiter.throttled",
+		metric.WithDescription("429 responses that backed off the client-side rate"))
+	cacheRequests, _ = meter.Int64Counter("http.client.cache.requests",
+		metric.WithDescription("GET requests by cache result: hit, miss, revalidated or bypass"))
+	faultsInjected, _ = meter.Int64Counter("http.client.faults.injected",
+		metric.WithDescription("Faults injected into outgoing requests"))
+	hedgesSent, _ = meter.Int64Counter("http.client.hedge.sent",
+		metric.WithDescription("Hedged attempts sent after the hedge delay"))
+	hedgeWins, _ = meter.Int64Counter("http.client.hedge.wins",
+		metric.WithDescription("Hedged requests by the attempt that answered first: primary or hedge"))
+	singleflightShared, _ = meter.Int64Counter("http.client.singleflight.shared",
+		metric.WithDescription("GET requests served from an identical request already in flight"))
+)
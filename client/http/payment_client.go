@@ -1,17 +1,20 @@
 package http
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log/slog"
-	"net/http"
+
+	"github.com/google/uuid"
 )
 
 type PaymentClientInterface interface {
-	DoSomething(ctx context.Context) error
+	CreatePaymentIntent(ctx context.Context, req CreatePaymentIntentRequest) (*PaymentIntent, error)
+	CapturePayment(ctx context.Context, paymentIntentID string, req CapturePaymentRequest) (*PaymentIntent, error)
+	RefundPayment(ctx context.Context, paymentIntentID string, req RefundPaymentRequest) (*Refund, error)
+	GetPaymentStatus(ctx context.Context, paymentIntentID string) (*PaymentIntent, error)
+	ListTransactions(ctx context.Context, req ListTransactionsRequest) (*TransactionList, error)
 }
 
 type PaymentClient struct {
@@ -19,36 +22,116 @@ type PaymentClient struct {
 }
 
 func NewPaymentClient(baseURL string) *PaymentClient {
-	return &PaymentClient{HTTPClient: NewHTTPClient(baseURL, slog.Default(), false, false)}
+	client := NewHTTPClient(baseURL, slog.Default(), false, false).
+		Use(WithTracing(), WithRetry(DefaultRetryPolicy())).
+		WithCircuitBreaker(NewCircuitBreaker(DefaultCircuitBreakerSettings()))
+	client.Logging.Redactor = paymentRedactor()
+	return &PaymentClient{HTTPClient: client}
 }
 
-func (c *PaymentClient) DoSomething(ctx context.Context) error {
-	url := c.BaseURL + "/payment/endpoint"
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+// CreatePaymentIntent authorizes a payment; with CaptureMethod "manual" it
+// must be captured separately
+func (c *PaymentClient) CreatePaymentIntent(ctx context.Context, req CreatePaymentIntentRequest) (*PaymentIntent, error) {
+	if err := req.Amount.Validate(); err != nil {
+		return nil, err
+	}
+	if req.Amount.Amount == 0 {
+		return nil, errors.New("amount must be positive")
+	}
+	intent, err := Post[CreatePaymentIntentRequest, PaymentIntent](ctx, c.HTTPClient, "/payments/intents", req,
+		idempotencyKey(req.IdempotencyKey))
 	if err != nil {
-		return err
+		return nil, paymentError("create payment intent", err)
 	}
-	resp, err := c.Client.Do(req)
+	return &intent, nil
+}
+
+// CapturePayment captures a manually captured payment intent
+func (c *PaymentClient) CapturePayment(ctx context.Context, paymentIntentID string, req CapturePaymentRequest) (*PaymentIntent, error) {
+	if req.Amount != nil {
+		if err := req.Amount.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	intent, err := Post[CapturePaymentRequest, PaymentIntent](ctx, c.HTTPClient,
+		"/payments/intents/"+PathEscape(paymentIntentID)+"/capture", req, idempotencyKey(req.IdempotencyKey))
 	if err != nil {
-		return err
+		return nil, paymentError("capture payment", err)
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("payment request failed: %s", resp.Status)
+	return &intent, nil
+}
+
+// RefundPayment refunds all or part of a captured payment
+func (c *PaymentClient) RefundPayment(ctx context.Context, paymentIntentID string, req RefundPaymentRequest) (*Refund, error) {
+	if req.Amount != nil {
+		if err := req.Amount.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	refund, err := Post[RefundPaymentRequest, Refund](ctx, c.HTTPClient,
+		"/payments/intents/"+PathEscape(paymentIntentID)+"/refunds", req, idempotencyKey(req.IdempotencyKey))
+	if err != nil {
+		return nil, paymentError("refund payment", err)
 	}
+	return &refund, nil
+}
 
-	bodyBytes, err := io.ReadAll(resp.Body)
+func (c *PaymentClient) GetPaymentStatus(ctx context.Context, paymentIntentID string) (*PaymentIntent, error) {
+	intent, err := Get[PaymentIntent](ctx, c.HTTPClient, "/payments/intents/"+PathEscape(paymentIntentID))
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+		return nil, paymentError("get payment status", err)
 	}
-	resp.Body = io.NopCloser(bytes.NewReader(bodyBytes)) // restore body for decoder
+	return &intent, nil
+}
 
-	// Unmarshal response into PaymentResponse struct
-	var paymentResp PaymentResponse
-	if err := json.NewDecoder(resp.Body).Decode(&paymentResp); err != nil {
-		return fmt.Errorf("failed to decode response: %w", err)
+// ListTransactions returns one page of transactions; pass NextCursor back
+// as Cursor to fetch the next page
+func (c *PaymentClient) ListTransactions(ctx context.Context, req ListTransactionsRequest) (*TransactionList, error) {
+	list, err := Get[TransactionList](ctx, c.HTTPClient, "/payments/transactions", WithQuery(req))
+	if err != nil {
+		return nil, paymentError("list transactions", err)
 	}
-	fmt.Printf("PaymentResponse: %+v\n", paymentResp)
+	return &list, nil
+}
+
+// idempotencyKey sends key, or a fresh one, as the Idempotency-Key header.
+// The header also lets RetryRoundTripper retry the POST safely.
+func idempotencyKey(key string) RequestOption {
+	if key == "" {
+		key = uuid.New().String()
+	}
+	return WithHeader("Idempotency-Key", key)
+}
+
+// paymentError unwraps the payment service's error body when there is one
+func paymentError(op string, err error) error {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		var body struct {
+			Error *PaymentAPIError `json:"error"`
+		}
+		if httpErr.Decode(&body) == nil && body.Error != nil {
+			return fmt.Errorf("%s: %w", op, &PaymentError{HTTPError: httpErr, API: body.Error})
+		}
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
+
+// PaymentError is an HTTPError carrying the payment service's error details
+type PaymentError struct {
+	*HTTPError
+	API *PaymentAPIError
+}
+
+func (e *PaymentError) Error() string {
+	return fmt.Sprintf("%s: %s", e.HTTPError.Status, e.API.Error())
+}
+
+func (e *PaymentError) Unwrap() error { return e.HTTPError }
 
-	return nil
+// paymentRedactor extends the default redaction with card data fields
+func paymentRedactor() *Redactor {
+	r := DefaultRedactor()
+	r.JSONPaths = append(r.JSONPaths, "**.card_number", "**.pan", "**.cvc", "**.cvv", "**.expiry")
+	return r
 }
@@ -1,18 +1,199 @@
 package http
 
-// PaymentResponse represents the structure of the payment API response
-// matching the provided JSON example.
-type PaymentResponse struct {
-	StringExample string      `json:"string_example"`
-	IntExample    int         `json:"int_example"`
-	FloatExample  float64     `json:"float_example"`
-	BooleanTrue   bool        `json:"boolean_true"`
-	BooleanFalse  bool        `json:"boolean_false"`
-	NullExample   interface{} `json:"null_example"`
-	ArrayExample  []any       `json:"array_example"`
-	ObjectExample struct {
-		NestedString string `json:"nested_string"`
-		NestedNumber int    `json:"nested_number"`
-		NestedArray  []int  `json:"nested_array"`
-	} `json:"object_example"`
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// majorAmountPattern matches a plain decimal amount such as "12", "12.5" or "-3.00"
+var majorAmountPattern = regexp.MustCompile(`^-?\d+(?:\.\d+)?$`)
+
+// currencyExponents lists ISO 4217 currencies whose minor unit is not 1/100
+var currencyExponents = map[string]int{
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+}
+
+// Money is an amount in the currency's minor units (cents, satang, ...)
+// to avoid floating point rounding
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"` // ISO 4217 code, e.g. "THB"
+}
+
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+func (m Money) Validate() error {
+	if !currencyPattern.MatchString(m.Currency) {
+		return fmt.Errorf("invalid currency %q", m.Currency)
+	}
+	if m.Amount < 0 {
+		return errors.New("amount must not be negative")
+	}
+	return nil
+}
+
+// String formats the amount in major units, e.g. "12.50 THB"
+func (m Money) String() string {
+	exp, ok := currencyExponents[m.Currency]
+	if !ok {
+		exp = 2
+	}
+	sign, amount := "", m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	if exp == 0 {
+		return fmt.Sprintf("%s%d %s", sign, amount, m.Currency)
+	}
+	digits := fmt.Sprintf("%0*d", exp+1, amount)
+	major, minor := digits[:len(digits)-exp], digits[len(digits)-exp:]
+	return sign + major + "." + minor + " " + m.Currency
+}
+
+type PaymentStatus string
+
+const (
+	PaymentStatusRequiresCapture   PaymentStatus = "requires_capture"
+	PaymentStatusProcessing        PaymentStatus = "processing"
+	PaymentStatusSucceeded         PaymentStatus = "succeeded"
+	PaymentStatusCanceled          PaymentStatus = "canceled"
+	PaymentStatusFailed            PaymentStatus = "failed"
+	PaymentStatusPartiallyRefunded PaymentStatus = "partially_refunded"
+	PaymentStatusRefunded          PaymentStatus = "refunded"
+)
+
+// PaymentIntent is a payment from authorization through capture and refunds
+type PaymentIntent struct {
+	ID             string            `json:"id"`
+	Amount         Money             `json:"amount"`
+	AmountCaptured Money             `json:"amount_captured"`
+	AmountRefunded Money             `json:"amount_refunded"`
+	Status         PaymentStatus     `json:"status"`
+	CaptureMethod  string            `json:"capture_method"`
+	Description    string            `json:"description,omitempty"`
+	CustomerID     string            `json:"customer_id,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	FailureReason  string            `json:"failure_reason,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+type CreatePaymentIntentRequest struct {
+	Amount        Money             `json:"amount"`
+	CaptureMethod string            `json:"capture_method,omitempty"` // "automatic" (default) or "manual"
+	Description   string            `json:"description,omitempty"`
+	CustomerID    string            `json:"customer_id,omitempty"`
+	PaymentMethod string            `json:"payment_method,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	// IdempotencyKey makes retries safe; generated when empty
+	IdempotencyKey string `json:"-"`
+}
+
+type CapturePaymentRequest struct {
+	// Amount to capture; nil captures the full authorized amount
+	Amount         *Money `json:"amount,omitempty"`
+	IdempotencyKey string `json:"-"`
+}
+
+type RefundPaymentRequest struct {
+	// Amount to refund; nil refunds everything captured so far
+	Amount         *Money `json:"amount,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+	IdempotencyKey string `json:"-"`
+}
+
+type RefundStatus string
+
+const (
+	RefundStatusPending   RefundStatus = "pending"
+	RefundStatusSucceeded RefundStatus = "succeeded"
+	RefundStatusFailed    RefundStatus = "failed"
+)
+
+type Refund struct {
+	ID              string       `json:"id"`
+	PaymentIntentID string       `json:"payment_intent_id"`
+	Amount          Money        `json:"amount"`
+	Status          RefundStatus `json:"status"`
+	Reason          string       `json:"reason,omitempty"`
+	CreatedAt       time.Time    `json:"created_at"`
+}
+
+type TransactionType string
+
+const (
+	TransactionTypeCharge TransactionType = "charge"
+	TransactionTypeRefund TransactionType = "refund"
+)
+
+type Transaction struct {
+	ID              string          `json:"id"`
+	PaymentIntentID string          `json:"payment_intent_id"`
+	Type            TransactionType `json:"type"`
+	Amount          Money           `json:"amount"`
+	Status          string          `json:"status"`
+	CreatedAt       time.Time       `json:"created_at"`
+}
+
+// ListTransactionsRequest filters ListTransactions; zero values are ignored
+type ListTransactionsRequest struct {
+	PaymentIntentID string    `url:"payment_intent_id,omitempty"`
+	From            time.Time `url:"created_gte,omitempty"`
+	To              time.Time `url:"created_lt,omitempty"`
+	Limit           int       `url:"limit,omitempty"`
+	Cursor          string    `url:"cursor,omitempty"`
+}
+
+type TransactionList struct {
+	Data       []Transaction `json:"data"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+	HasMore    bool          `json:"has_more"`
+}
+
+// PaymentAPIError is the error body returned by the payment service
+type PaymentAPIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Param   string `json:"param,omitempty"`
+}
+
+func (e *PaymentAPIError) Error() string {
+	if e.Param != "" {
+		return e.Code + " (" + e.Param + "): " + e.Message
+	}
+	return e.Code + ": " + e.Message
+}
+
+// ParseMoney converts a major-unit amount such as "12.50" into Money
+func ParseMoney(major string, currency string) (Money, error) {
+	currency = strings.ToUpper(currency)
+	exp, ok := currencyExponents[currency]
+	if !ok {
+		exp = 2
+	}
+	major = strings.TrimSpace(major)
+	if !majorAmountPattern.MatchString(major) {
+		return Money{}, fmt.Errorf("invalid amount %q", major)
+	}
+	whole, frac, _ := strings.Cut(major, ".")
+	if len(frac) > exp {
+		return Money{}, fmt.Errorf("too many decimal places for %s: %s", currency, major)
+	}
+	frac += strings.Repeat("0", exp-len(frac))
+	amount, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q", major)
+	}
+	m := Money{Amount: amount, Currency: currency}
+	return m, m.Validate()
 }
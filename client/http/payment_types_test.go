@@ -0,0 +1,76 @@
+package http
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		major    string
+		currency string
+		want     Money
+		wantErr  string
+	}{
+		{major: "12.50", currency: "thb", want: Money{Amount: 1250, Currency: "THB"}},
+		{major: " 12.5 ", currency: "THB", want: Money{Amount: 1250, Currency: "THB"}},
+		{major: "12", currency: "THB", want: Money{Amount: 1200, Currency: "THB"}},
+		{major: "0.05", currency: "USD", want: Money{Amount: 5, Currency: "USD"}},
+		{major: "1500", currency: "JPY", want: Money{Amount: 1500, Currency: "JPY"}},
+		{major: "1.234", currency: "KWD", want: Money{Amount: 1234, Currency: "KWD"}},
+		{major: "1.005", currency: "THB", wantErr: "too many decimal places"},
+		{major: "1500.5", currency: "JPY", wantErr: "too many decimal places"},
+		{major: "1.2345", currency: "KWD", wantErr: "too many decimal places"},
+		{major: "-1.50", currency: "THB", wantErr: "must not be negative"},
+		{major: "", currency: "THB", wantErr: "invalid amount"},
+		{major: ".", currency: "THB", wantErr: "invalid amount"},
+		{major: ".50", currency: "THB", wantErr: "invalid amount"},
+		{major: "12.", currency: "THB", wantErr: "invalid amount"},
+		{major: "+12", currency: "THB", wantErr: "invalid amount"},
+		{major: "1,000.00", currency: "THB", wantErr: "invalid amount"},
+		{major: "1e3", currency: "THB", wantErr: "invalid amount"},
+		{major: "abc", currency: "THB", wantErr: "invalid amount"},
+		{major: "99999999999999999999", currency: "THB", wantErr: "invalid amount"},
+		{major: "12.50", currency: "BAHT", wantErr: "invalid currency"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.major+" "+tt.currency, func(t *testing.T) {
+			got, err := ParseMoney(tt.major, tt.currency)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseMoney() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{money: NewMoney(1250, "thb"), want: "12.50 THB"},
+		{money: NewMoney(5, "USD"), want: "0.05 USD"},
+		{money: NewMoney(0, "USD"), want: "0.00 USD"},
+		{money: NewMoney(-150, "USD"), want: "-1.50 USD"},
+		{money: NewMoney(1500, "JPY"), want: "1500 JPY"},
+		{money: NewMoney(-1500, "JPY"), want: "-1500 JPY"},
+		{money: NewMoney(1234, "KWD"), want: "1.234 KWD"},
+		{money: NewMoney(7, "KWD"), want: "0.007 KWD"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.money.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PaymentSignatureHeader carries "t=<unix seconds>,v1=<hex hmac>" where the
+// HMAC-SHA256 is computed over "<t>.<raw body>" with the webhook secret.
+// Several v1 entries may be present while secrets are being rotated.
+const PaymentSignatureHeader = "Payment-Signature"
+
+var (
+	ErrWebhookSignature = errors.New("invalid webhook signature")
+	ErrWebhookTimestamp = errors.New("webhook timestamp outside tolerance")
+)
+
+// Payment event types
+const (
+	EventPaymentSucceeded = "payment_intent.succeeded"
+	EventPaymentFailed    = "payment_intent.failed"
+	EventPaymentCanceled  = "payment_intent.canceled"
+	EventRefundSucceeded  = "refund.succeeded"
+	EventRefundFailed     = "refund.failed"
+)
+
+// PaymentEvent is the envelope of every payment webhook
+type PaymentEvent struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// PaymentIntent decodes the data of payment_intent.* events
+func (e *PaymentEvent) PaymentIntent() (*PaymentIntent, error) {
+	var intent PaymentIntent
+	if err := json.Unmarshal(e.Data, &intent); err != nil {
+		return nil, fmt.Errorf("decode %s event data: %w", e.Type, err)
+	}
+	return &intent, nil
+}
+
+// Refund decodes the data of refund.* events
+func (e *PaymentEvent) Refund() (*Refund, error) {
+	var refund Refund
+	if err := json.Unmarshal(e.Data, &refund); err != nil {
+		return nil, fmt.Errorf("decode %s event data: %w", e.Type, err)
+	}
+	return &refund, nil
+}
+
+// VerifyPaymentWebhook checks the signature header against body and returns
+// the decoded event. Events signed more than tolerance away from now are
+// rejected to limit replays.
+func VerifyPaymentWebhook(secret []byte, header string, body []byte, tolerance time.Duration, now time.Time) (*PaymentEvent, error) {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if timestamp == "" || len(signatures) == 0 {
+		return nil, ErrWebhookSignature
+	}
+	secs, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, ErrWebhookSignature
+	}
+	signedAt := time.Unix(secs, 0)
+	if d := now.Sub(signedAt); d > tolerance || d < -tolerance {
+		return nil, ErrWebhookTimestamp
+	}
+
+	expected := SignPaymentWebhook(secret, timestamp, body)
+	valid := false
+	for _, sig := range signatures {
+		if hmac.Equal([]byte(sig), []byte(expected)) {
+			valid = true
+		}
+	}
+	if !valid {
+		return nil, ErrWebhookSignature
+	}
+
+	var event PaymentEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("decode webhook event: %w", err)
+	}
+	if event.ID == "" || event.Type == "" {
+		return nil, errors.New("webhook event is missing id or type")
+	}
+	return &event, nil
+}
+
+// SignPaymentWebhook returns the hex v1 signature for a timestamp and body
+func SignPaymentWebhook(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
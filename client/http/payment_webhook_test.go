@@ -0,0 +1,75 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestVerifyPaymentWebhook(t *testing.T) {
+	secret := []byte("whsec")
+	oldSecret := []byte("whsec-old")
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"id":"evt_1","type":"payment_intent.succeeded","data":{}}`)
+	signed := func(secret []byte, at time.Time, body []byte) string {
+		ts := fmt.Sprint(at.Unix())
+		return "t=" + ts + ",v1=" + SignPaymentWebhook(secret, ts, body)
+	}
+	ts := fmt.Sprint(now.Unix())
+
+	tests := []struct {
+		name    string
+		header  string
+		body    []byte
+		wantErr error // nil when the event should be accepted
+	}{
+		{name: "valid", header: signed(secret, now, body), body: body},
+		{name: "within tolerance", header: signed(secret, now.Add(-4*time.Minute), body), body: body},
+		{
+			name:   "rotation with several v1 entries",
+			header: "t=" + ts + ",v1=" + SignPaymentWebhook(oldSecret, ts, body) + ", v1=" + SignPaymentWebhook(secret, ts, body),
+			body:   body,
+		},
+		{name: "missing header", header: "", body: body, wantErr: ErrWebhookSignature},
+		{name: "missing timestamp", header: "v1=" + SignPaymentWebhook(secret, ts, body), body: body, wantErr: ErrWebhookSignature},
+		{name: "missing signature", header: "t=" + ts, body: body, wantErr: ErrWebhookSignature},
+		{name: "malformed timestamp", header: "t=yesterday,v1=abc", body: body, wantErr: ErrWebhookSignature},
+		{name: "wrong secret", header: signed(oldSecret, now, body), body: body, wantErr: ErrWebhookSignature},
+		{name: "tampered body", header: signed(secret, now, body), body: []byte(`{"id":"evt_1","type":"refund.succeeded","data":{}}`), wantErr: ErrWebhookSignature},
+		{name: "stale timestamp", header: signed(secret, now.Add(-6*time.Minute), body), body: body, wantErr: ErrWebhookTimestamp},
+		{name: "future timestamp", header: signed(secret, now.Add(6*time.Minute), body), body: body, wantErr: ErrWebhookTimestamp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := VerifyPaymentWebhook(secret, tt.header, tt.body, 5*time.Minute, now)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if event.ID != "evt_1" || event.Type != EventPaymentSucceeded {
+				t.Errorf("event = %+v", event)
+			}
+		})
+	}
+}
+
+func TestVerifyPaymentWebhookRejectsInvalidEvents(t *testing.T) {
+	secret := []byte("whsec")
+	now := time.Unix(1_700_000_000, 0)
+	ts := fmt.Sprint(now.Unix())
+	for _, body := range []string{`not json`, `{"type":"refund.succeeded"}`, `{"id":"evt_1"}`} {
+		t.Run(body, func(t *testing.T) {
+			header := "t=" + ts + ",v1=" + SignPaymentWebhook(secret, ts, []byte(body))
+			_, err := VerifyPaymentWebhook(secret, header, []byte(body), time.Minute, now)
+			if err == nil || errors.Is(err, ErrWebhookSignature) {
+				t.Fatalf("err = %v, want a decoding error", err)
+			}
+		})
+	}
+}
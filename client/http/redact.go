@@ -0,0 +1,260 @@
+package http
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const redacted = "[REDACTED]"
+
+var (
+	// panPattern matches 13-19 digit card numbers, optionally grouped by spaces or dashes
+	panPattern = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	// emailPattern matches email addresses
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+)
+
+// alwaysMaskedHeaders are masked regardless of allow lists
+var alwaysMaskedHeaders = []string{"Authorization", "Proxy-Authorization"}
+
+// Scrubber replaces sensitive substrings in logged text
+type Scrubber struct {
+	Pattern *regexp.Regexp
+	// Validate, when set, must accept a match before it is replaced
+	Validate func(match string) bool
+}
+
+// PANScrubber masks Luhn-valid card numbers
+func PANScrubber() Scrubber {
+	return Scrubber{Pattern: panPattern, Validate: luhnValid}
+}
+
+// EmailScrubber masks email addresses
+func EmailScrubber() Scrubber {
+	return Scrubber{Pattern: emailPattern}
+}
+
+// Redactor decides what LoggingRoundTripper may write to the log. Configure
+// it before first use; its fields must not change afterwards.
+type Redactor struct {
+	// HeaderAllow, when non-empty, lists the only headers logged in clear;
+	// every other header is masked
+	HeaderAllow []string
+	// HeaderDeny lists headers that are always masked
+	HeaderDeny []string
+	// QueryParams lists URL query parameters whose values are masked
+	QueryParams []string
+	// JSONPaths lists body fields to mask, e.g. "card.number". "*" matches
+	// any key at one level and "**" any number of levels, so "**.token"
+	// masks every token field.
+	JSONPaths []string
+	// Scrubbers run over bodies after JSON path masking
+	Scrubbers []Scrubber
+	// BodyContentTypes lists media types whose bodies are logged; a trailing
+	// "/*" matches a whole family. Other bodies are omitted.
+	BodyContentTypes []string
+
+	compileOnce  sync.Once
+	fieldPattern *regexp.Regexp // masks JSONPaths fields by name, nil when none apply
+}
+
+// DefaultRedactor masks credentials, card numbers and email addresses and
+// only logs textual bodies
+func DefaultRedactor() *Redactor {
+	return &Redactor{
+		HeaderDeny:  []string{"Cookie", "Set-Cookie", "X-Api-Key", "Api-Key"},
+		QueryParams: []string{"access_token", "token", "api_key", "code", "sig"},
+		JSONPaths:   []string{"**.password", "**.token", "**.access_token", "**.refresh_token", "**.secret"},
+		Scrubbers:   []Scrubber{PANScrubber(), EmailScrubber()},
+		BodyContentTypes: []string{
+			"application/json", "application/problem+json", "application/x-www-form-urlencoded",
+			"application/xml", "text/*",
+		},
+	}
+}
+
+// Headers returns a copy of h with sensitive values masked
+func (r *Redactor) Headers(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for name, values := range h {
+		value := strings.Join(values, ", ")
+		if r.maskHeader(name) {
+			value = redacted
+		}
+		out[name] = value
+	}
+	return out
+}
+
+func (r *Redactor) maskHeader(name string) bool {
+	for _, h := range alwaysMaskedHeaders {
+		if strings.EqualFold(h, name) {
+			return true
+		}
+	}
+	for _, h := range r.HeaderDeny {
+		if strings.EqualFold(h, name) {
+			return true
+		}
+	}
+	if len(r.HeaderAllow) == 0 {
+		return false
+	}
+	for _, h := range r.HeaderAllow {
+		if strings.EqualFold(h, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// URL returns u as a string with masked query parameters and no credentials
+func (r *Redactor) URL(u *url.URL) string {
+	clean := *u
+	clean.User = nil
+	if clean.RawQuery == "" || len(r.QueryParams) == 0 {
+		return clean.String()
+	}
+	q := clean.Query()
+	for _, p := range r.QueryParams {
+		if _, ok := q[p]; ok {
+			q.Set(p, redacted)
+		}
+	}
+	clean.RawQuery = q.Encode()
+	return clean.String()
+}
+
+// LogBody reports whether bodies of contentType should be logged
+func (r *Redactor) LogBody(contentType string) bool {
+	if len(r.BodyContentTypes) == 0 {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		// No or malformed Content-Type: only log what looks like text
+		return contentType == ""
+	}
+	for _, allowed := range r.BodyContentTypes {
+		if allowed == mediaType {
+			return true
+		}
+		if family, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(mediaType, family+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Body masks configured JSON fields and applies the scrubbers. Bodies that
+// are not valid JSON (including ones cut short by the log size limit) fall
+// back to masking "field": value pairs by name.
+func (r *Redactor) Body(body []byte) string {
+	text := string(body)
+	if len(r.JSONPaths) > 0 {
+		var doc any
+		if err := json.Unmarshal(body, &doc); err == nil {
+			for _, p := range r.JSONPaths {
+				maskPath(doc, strings.Split(strings.TrimPrefix(p, "$."), "."))
+			}
+			if b, err := json.Marshal(doc); err == nil {
+				text = string(b)
+			}
+		} else {
+			text = r.maskFieldsByName(text)
+		}
+	}
+	for _, s := range r.Scrubbers {
+		text = s.Pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if s.Validate != nil && !s.Validate(match) {
+				return match
+			}
+			return redacted
+		})
+	}
+	return text
+}
+
+// maskPath replaces the fields matched by path. Arrays are transparent, so
+// "items.token" masks the token of every item.
+func maskPath(node any, path []string) {
+	if len(path) == 0 {
+		return
+	}
+	switch n := node.(type) {
+	case []any:
+		for _, v := range n {
+			maskPath(v, path)
+		}
+	case map[string]any:
+		key, rest := path[0], path[1:]
+		if key == "**" {
+			maskPath(n, rest)
+			for _, v := range n {
+				maskPath(v, path)
+			}
+			return
+		}
+		for k, v := range n {
+			if key != "*" && k != key {
+				continue
+			}
+			if len(rest) == 0 {
+				n[k] = redacted
+				continue
+			}
+			maskPath(v, rest)
+		}
+	}
+}
+
+func (r *Redactor) maskFieldsByName(text string) string {
+	r.compileOnce.Do(r.compileFieldPattern)
+	if r.fieldPattern == nil {
+		return text
+	}
+	return r.fieldPattern.ReplaceAllString(text, `${1}"`+redacted+`"`)
+}
+
+// compileFieldPattern builds one regexp matching a "field": value pair for
+// the last segment of every JSON path
+func (r *Redactor) compileFieldPattern() {
+	var fields []string
+	for _, p := range r.JSONPaths {
+		parts := strings.Split(p, ".")
+		field := parts[len(parts)-1]
+		if field == "*" || field == "**" {
+			continue
+		}
+		fields = append(fields, regexp.QuoteMeta(field))
+	}
+	if len(fields) == 0 {
+		return
+	}
+	r.fieldPattern = regexp.MustCompile(`("(?:` + strings.Join(fields, "|") + `)"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`)
+}
+
+func luhnValid(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
@@ -0,0 +1,159 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures RetryRoundTripper
+type RetryPolicy struct {
+	MaxAttempts   int           // total attempts including the first
+	BaseDelay     time.Duration // backoff before the second attempt, doubled each time
+	MaxDelay      time.Duration // cap for a single backoff; a longer Retry-After ends retrying
+	MaxElapsed    time.Duration // total time budget across attempts, 0 for no budget
+	RetryStatuses []int         // response codes worth retrying
+}
+
+// DefaultRetryPolicy retries transient failures three times within ten seconds
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   200 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+		MaxElapsed:  10 * time.Second,
+		RetryStatuses: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// RetryRoundTripper retries failed requests with jittered exponential backoff.
+// Only idempotent requests are retried: GET, HEAD, OPTIONS, TRACE, PUT and
+// DELETE, plus POST and PATCH carrying an Idempotency-Key header. Requests
+// with a body are retried only when it can be rewound via GetBody.
+type RetryRoundTripper struct {
+	Proxied http.RoundTripper
+	Policy  RetryPolicy
+}
+
+// WithRetry returns a Middleware applying policy
+func WithRetry(policy RetryPolicy) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &RetryRoundTripper{Proxied: next, Policy: policy}
+	}
+}
+
+func (rrt *RetryRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !retryable(req) {
+		return rrt.Proxied.RoundTrip(req)
+	}
+	start := time.Now()
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 {
+			req = req.Clone(withAttempt(ctx, attempt))
+			if req.Body != nil && req.Body != http.NoBody {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				req.Body = body
+			}
+		}
+		resp, err := rrt.Proxied.RoundTrip(req)
+		if attempt >= rrt.Policy.MaxAttempts || req.Context().Err() != nil || !rrt.shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		delay := rrt.backoff(attempt)
+		if resp != nil {
+			if d, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				// Never retry sooner than the server asked; if it asks for
+				// longer than we are willing to wait, let the caller decide
+				if rrt.Policy.MaxDelay > 0 && d > rrt.Policy.MaxDelay {
+					return resp, err
+				}
+				delay = d
+			}
+		}
+		if rrt.Policy.MaxElapsed > 0 && time.Since(start)+delay > rrt.Policy.MaxElapsed {
+			return resp, err // out of budget, hand back the last outcome
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+			return resp, err // the next attempt could not finish in time
+		}
+		if resp != nil {
+			// Drain so the connection can be reused
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (rrt *RetryRoundTripper) shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) &&
+			!errors.Is(err, ErrCircuitOpen) && !isLocalRejection(err) && !errors.Is(err, ErrNoInteraction)
+	}
+	for _, code := range rrt.Policy.RetryStatuses {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns a full-jitter delay for the given attempt
+func (rrt *RetryRoundTripper) backoff(attempt int) time.Duration {
+	ceiling := rrt.Policy.BaseDelay << (attempt - 1)
+	if ceiling <= 0 || (rrt.Policy.MaxDelay > 0 && ceiling > rrt.Policy.MaxDelay) {
+		ceiling = rrt.Policy.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling)
+}
+
+func retryable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	case http.MethodPost, http.MethodPatch:
+		return req.Header.Get("Idempotency-Key") != ""
+	}
+	return false
+}
+
+// parseRetryAfter reads a Retry-After value given in seconds or as an HTTP date
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,130 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func response(req *http.Request, status int, header http.Header) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{StatusCode: status, Header: header, Body: io.NopCloser(strings.NewReader("")), Request: req}
+}
+
+// scriptedTransport answers with statuses in order, repeating the last one
+type scriptedTransport struct {
+	statuses []int
+	header   http.Header // sent with every non-2xx response
+	bodies   []string
+}
+
+func (s *scriptedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	n := len(s.bodies)
+	body := ""
+	if req.Body != nil {
+		b, _ := io.ReadAll(req.Body)
+		body = string(b)
+	}
+	s.bodies = append(s.bodies, body)
+	status := s.statuses[min(n, len(s.statuses)-1)]
+	if status < 300 {
+		return response(req, status, nil), nil
+	}
+	return response(req, status, s.header.Clone()), nil
+}
+
+func TestRetryRoundTripper(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts:   3,
+		BaseDelay:     time.Millisecond,
+		MaxDelay:      50 * time.Millisecond,
+		RetryStatuses: []int{http.StatusTooManyRequests, http.StatusServiceUnavailable},
+	}
+	tests := []struct {
+		name         string
+		method       string
+		header       http.Header // request headers
+		body         string
+		statuses     []int
+		respHeader   http.Header
+		wantStatus   int
+		wantAttempts int
+	}{
+		{name: "retries until success", method: "GET", statuses: []int{503, 503, 200}, wantStatus: 200, wantAttempts: 3},
+		{name: "stops at max attempts", method: "GET", statuses: []int{503}, wantStatus: 503, wantAttempts: 3},
+		{name: "does not retry other statuses", method: "GET", statuses: []int{404}, wantStatus: 404, wantAttempts: 1},
+		{name: "does not retry POST without a key", method: "POST", body: "{}", statuses: []int{503, 200}, wantStatus: 503, wantAttempts: 1},
+		{
+			name: "retries POST with a key and rewinds the body", method: "POST", body: `{"amount":1}`,
+			header: http.Header{"Idempotency-Key": {"k"}}, statuses: []int{503, 200}, wantStatus: 200, wantAttempts: 2,
+		},
+		{
+			name: "honours a short Retry-After", method: "GET", statuses: []int{429, 200},
+			respHeader: http.Header{"Retry-After": {"0"}}, wantStatus: 200, wantAttempts: 2,
+		},
+		{
+			name: "gives up when Retry-After exceeds MaxDelay", method: "GET", statuses: []int{429, 200},
+			respHeader: http.Header{"Retry-After": {"1"}}, wantStatus: 429, wantAttempts: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &scriptedTransport{statuses: tt.statuses, header: tt.respHeader}
+			rt := WithRetry(policy)(transport)
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+			req, _ := http.NewRequest(tt.method, "http://example.com/x", body)
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+
+			resp, err := rt.RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if len(transport.bodies) != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", len(transport.bodies), tt.wantAttempts)
+			}
+			for i, b := range transport.bodies {
+				if b != tt.body {
+					t.Errorf("attempt %d body = %q, want %q", i+1, b, tt.body)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryRoundTripperStopsBeforeDeadline(t *testing.T) {
+	transport := &scriptedTransport{statuses: []int{429, 200}, header: http.Header{"Retry-After": {"5"}}}
+	// No MaxDelay, so only the deadline stops the wait
+	rt := WithRetry(RetryPolicy{MaxAttempts: 3, RetryStatuses: []int{http.StatusTooManyRequests}})(transport)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", "http://example.com/x", nil)
+
+	start := time.Now()
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("err = %v, want the 429 response", err)
+	}
+	if resp.StatusCode != http.StatusTooManyRequests || len(transport.bodies) != 1 {
+		t.Fatalf("status %d after %d attempts, want 429 after 1", resp.StatusCode, len(transport.bodies))
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatal("waited for a retry that could not finish before the deadline")
+	}
+}
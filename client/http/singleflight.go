@@ -0,0 +1,124 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// SingleflightRoundTripper collapses identical concurrent GETs into one
+// downstream request whose response is shared. Requests are identical when
+// their URL and headers match. If the shared request fails because its
+// caller went away, waiters with a live context send their own.
+type SingleflightRoundTripper struct {
+	Proxied http.RoundTripper
+
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+type flight struct {
+	done   chan struct{}
+	resp   *http.Response // body already read into body
+	body   []byte
+	err    error
+	shared int
+}
+
+// WithSingleflight returns a Middleware collapsing concurrent GETs
+func WithSingleflight() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &SingleflightRoundTripper{Proxied: next, calls: map[string]*flight{}}
+	}
+}
+
+// WithSingleflight installs GET deduplication as the outermost middleware
+func (c *HTTPClient) WithSingleflight() *HTTPClient {
+	return c.Use(WithSingleflight())
+}
+
+func (srt *SingleflightRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || (req.Body != nil && req.Body != http.NoBody) {
+		return srt.Proxied.RoundTrip(req)
+	}
+	key := flightKey(req)
+
+	srt.mu.Lock()
+	if f, ok := srt.calls[key]; ok {
+		f.shared++
+		srt.mu.Unlock()
+		return srt.wait(req, f)
+	}
+	f := &flight{done: make(chan struct{})}
+	srt.calls[key] = f
+	srt.mu.Unlock()
+
+	resp, err := srt.Proxied.RoundTrip(req)
+	if err == nil {
+		f.body, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	f.resp, f.err = resp, err
+
+	srt.mu.Lock()
+	delete(srt.calls, key)
+	shared := f.shared
+	srt.mu.Unlock()
+	close(f.done)
+
+	if shared > 0 {
+		singleflightShared.Add(req.Context(), int64(shared), metric.WithAttributes(attribute.String("server.address", req.URL.Host)))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f.response(req), nil
+}
+
+func (srt *SingleflightRoundTripper) wait(req *http.Request, f *flight) (*http.Response, error) {
+	select {
+	case <-f.done:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	if f.err != nil {
+		if (errors.Is(f.err, context.Canceled) || errors.Is(f.err, context.DeadlineExceeded)) && req.Context().Err() == nil {
+			return srt.Proxied.RoundTrip(req)
+		}
+		return nil, f.err
+	}
+	return f.response(req), nil
+}
+
+// response returns a private copy of the shared response
+func (f *flight) response(req *http.Request) *http.Response {
+	resp := *f.resp
+	resp.Header = f.resp.Header.Clone()
+	resp.Body = io.NopCloser(bytes.NewReader(f.body))
+	resp.ContentLength = int64(len(f.body))
+	resp.Request = req
+	return &resp
+}
+
+// flightKey identifies a request by URL and headers
+func flightKey(req *http.Request) string {
+	var b strings.Builder
+	b.WriteString(req.URL.String())
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		b.WriteString("\n" + name + ": " + strings.Join(req.Header[name], ", "))
+	}
+	return b.String()
+}
@@ -0,0 +1,150 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// gatedTransport holds every request until release is closed, or until the
+// request's context is done
+type gatedTransport struct {
+	release chan struct{}
+	calls   atomic.Int32
+	started chan struct{} // receives once per request
+}
+
+func newGatedTransport() *gatedTransport {
+	return &gatedTransport{release: make(chan struct{}), started: make(chan struct{}, 16)}
+}
+
+func (g *gatedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	n := g.calls.Add(1)
+	g.started <- struct{}{}
+	select {
+	case <-g.release:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	resp := response(req, http.StatusOK, nil)
+	resp.Body = io.NopCloser(strings.NewReader("member " + strconv.Itoa(int(n))))
+	return resp, nil
+}
+
+func getBody(rt http.RoundTripper, ctx context.Context, header string) (string, error) {
+	req, _ := http.NewRequestWithContext(ctx, "GET", "http://members.example.com/members/1", nil)
+	if header != "" {
+		req.Header.Set("Accept-Language", header)
+	}
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	return string(b), err
+}
+
+func TestSingleflightSharesConcurrentGets(t *testing.T) {
+	transport := newGatedTransport()
+	rt := WithSingleflight()(transport)
+	var wg sync.WaitGroup
+	bodies := make([]string, 5)
+	for i := range bodies {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			bodies[i], _ = getBody(rt, context.Background(), "")
+		}()
+	}
+	<-transport.started
+	time.Sleep(20 * time.Millisecond) // let the others join the flight
+	close(transport.release)
+	wg.Wait()
+
+	if n := transport.calls.Load(); n != 1 {
+		t.Fatalf("downstream calls = %d, want 1", n)
+	}
+	for i, b := range bodies {
+		if b != "member 1" {
+			t.Errorf("caller %d body = %q, want the shared response", i, b)
+		}
+	}
+}
+
+func TestSingleflightKeysOnHeaders(t *testing.T) {
+	transport := newGatedTransport()
+	close(transport.release)
+	rt := WithSingleflight()(transport)
+	getBody(rt, context.Background(), "en")
+	getBody(rt, context.Background(), "th")
+	if n := transport.calls.Load(); n != 2 {
+		t.Fatalf("downstream calls = %d, want 2", n)
+	}
+}
+
+func TestSingleflightLeaderCancelled(t *testing.T) {
+	transport := newGatedTransport()
+	rt := WithSingleflight()(transport)
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := getBody(rt, leaderCtx, "")
+		leaderErr <- err
+	}()
+	<-transport.started
+
+	waiter := make(chan string, 1)
+	go func() {
+		b, err := getBody(rt, context.Background(), "")
+		if err != nil {
+			b = err.Error()
+		}
+		waiter <- b
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancelLeader()
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("leader err = %v, want canceled", err)
+	}
+
+	// The waiter's context is live, so it sends its own request
+	<-transport.started
+	close(transport.release)
+	if b := <-waiter; b != "member 2" {
+		t.Fatalf("waiter got %q, want its own response", b)
+	}
+}
+
+func TestSingleflightWaiterCancelled(t *testing.T) {
+	transport := newGatedTransport()
+	rt := WithSingleflight()(transport)
+	leader := make(chan string, 1)
+	go func() {
+		b, _ := getBody(rt, context.Background(), "")
+		leader <- b
+	}()
+	<-transport.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := getBody(rt, ctx, ""); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("waiter err = %v, want deadline exceeded", err)
+	}
+
+	// The leader is unaffected by a waiter giving up
+	close(transport.release)
+	if b := <-leader; b != "member 1" {
+		t.Fatalf("leader got %q", b)
+	}
+	if n := transport.calls.Load(); n != 1 {
+		t.Fatalf("downstream calls = %d, want 1", n)
+	}
+}
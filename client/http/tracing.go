@@ -0,0 +1,109 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "azure-client-go/client/http"
+
+// TracingRoundTripper starts a client span for every outgoing request and
+// injects the W3C trace context and baggage into its headers
+type TracingRoundTripper struct {
+	Proxied    http.RoundTripper
+	Tracer     trace.Tracer                  // defaults to the global provider
+	Propagator propagation.TextMapPropagator // defaults to the global propagator
+	Redactor   *Redactor                     // masks url.full query parameters; DefaultRedactor when nil
+}
+
+// WithTracing returns a Middleware creating client spans. Install it before
+// WithRetry so every attempt gets its own span.
+func WithTracing() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &TracingRoundTripper{Proxied: next}
+	}
+}
+
+func (trt *TracingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	tracer := trt.Tracer
+	if tracer == nil {
+		tracer = otel.Tracer(tracerName)
+	}
+	propagator := trt.Propagator
+	if propagator == nil {
+		propagator = otel.GetTextMapPropagator()
+	}
+	redactor := trt.Redactor
+	if redactor == nil {
+		redactor = DefaultRedactor()
+	}
+
+	ctx, span := tracer.Start(req.Context(), req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(requestAttributes(req, redactor)...),
+	)
+	defer span.End()
+
+	req = req.Clone(ctx)
+	propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := trt.Proxied.RoundTrip(req)
+	if err != nil {
+		span.SetAttributes(semconv.ErrorTypeKey.String(fmt.Sprintf("%T", err)))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		span.SetAttributes(semconv.ErrorTypeKey.String(strconv.Itoa(resp.StatusCode)))
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	return resp, nil
+}
+
+// requestAttributes describes req; url.full never carries credentials or
+// the values of query parameters the redactor masks
+func requestAttributes(req *http.Request, redactor *Redactor) []attribute.KeyValue {
+	u := req.URL
+	attrs := []attribute.KeyValue{
+		semconv.HTTPRequestMethodKey.String(req.Method),
+		semconv.URLFull(redactor.URL(u)),
+		semconv.ServerAddress(u.Hostname()),
+	}
+	port := u.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[u.Scheme]
+	}
+	if p, err := strconv.Atoi(port); err == nil {
+		attrs = append(attrs, semconv.ServerPort(p))
+	}
+	if ua := req.UserAgent(); ua != "" {
+		attrs = append(attrs, semconv.UserAgentOriginal(ua))
+	}
+	if n := attemptFromContext(req.Context()); n > 1 {
+		attrs = append(attrs, semconv.HTTPRequestResendCount(n-1))
+	}
+	return attrs
+}
+
+type attemptKey struct{}
+
+// withAttempt records the attempt number so inner middlewares can report resends
+func withAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+func attemptFromContext(ctx context.Context) int {
+	n, _ := ctx.Value(attemptKey{}).(int)
+	return n
+}
@@ -0,0 +1,188 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// TransportOptions configures the network transport of an HTTPClient
+type TransportOptions struct {
+	// CAFile is a PEM bundle trusted in addition to the system roots
+	CAFile string
+	// CertFile and KeyFile hold the client certificate for mTLS
+	CertFile string
+	KeyFile  string
+	// CertReloadInterval is how often the certificate files are checked for
+	// changes, so rotated certificates are picked up without a restart; 0
+	// loads them once
+	CertReloadInterval time.Duration
+	// ProxyURL routes requests through a proxy; empty uses HTTPS_PROXY,
+	// HTTP_PROXY and NO_PROXY from the environment and "direct" disables
+	// proxying
+	ProxyURL string
+
+	MaxIdleConns          int
+	MaxIdleConnsPerHost   int
+	MaxConnsPerHost       int // 0 for no limit
+	IdleConnTimeout       time.Duration
+	DialTimeout           time.Duration
+	KeepAlive             time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration // 0 leaves it to the client timeout
+	DisableHTTP2          bool
+}
+
+// DefaultTransportOptions matches http.DefaultTransport with a larger
+// per-host idle pool
+func DefaultTransportOptions() TransportOptions {
+	return TransportOptions{
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
+		DialTimeout:         30 * time.Second,
+		KeepAlive:           30 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+}
+
+// NewTransport builds an *http.Transport from opts
+func NewTransport(opts TransportOptions) (*http.Transport, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if opts.CAFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", opts.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		if opts.CertFile == "" || opts.KeyFile == "" {
+			return nil, errors.New("mTLS needs both a certificate and a key file")
+		}
+		reloader, err := newCertReloader(opts.CertFile, opts.KeyFile, opts.CertReloadInterval)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.GetClientCertificate = reloader.GetClientCertificate
+	}
+
+	proxy := http.ProxyFromEnvironment
+	switch opts.ProxyURL {
+	case "":
+	case "direct":
+		proxy = nil
+	default:
+		u, err := url.Parse(opts.ProxyURL)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", opts.ProxyURL)
+		}
+		proxy = http.ProxyURL(u)
+	}
+
+	dialer := &net.Dialer{Timeout: opts.DialTimeout, KeepAlive: opts.KeepAlive}
+	t := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     !opts.DisableHTTP2,
+		MaxIdleConns:          opts.MaxIdleConns,
+		MaxIdleConnsPerHost:   opts.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       opts.MaxConnsPerHost,
+		IdleConnTimeout:       opts.IdleConnTimeout,
+		TLSHandshakeTimeout:   opts.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: opts.ResponseHeaderTimeout,
+		ExpectContinueTimeout: time.Second,
+	}
+	if opts.DisableHTTP2 {
+		// A non-nil empty map stops the transport from negotiating h2
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return t, nil
+}
+
+// baseTransport is the network transport at the bottom of the chain. The
+// middleware installed beneath logging wraps it rather than the transport
+// itself, so the transport can be replaced without unwinding them.
+type baseTransport struct {
+	http.RoundTripper
+}
+
+// WithTransport replaces the network transport beneath logging, keeping any
+// middleware already installed there (WithAuth, WithLimiter, WithRecorder,
+// WithFaultInjector)
+func (c *HTTPClient) WithTransport(t http.RoundTripper) *HTTPClient {
+	if c.base == nil {
+		// Clients not built by NewHTTPClient have no base to swap yet
+		c.base = &baseTransport{}
+		c.Logging.Proxied = c.base
+	}
+	c.base.RoundTripper = t
+	return c
+}
+
+// certReloader serves the client certificate, re-reading the files when
+// their modification time changes. A failed reload keeps the previous
+// certificate.
+type certReloader struct {
+	certFile, keyFile string
+	interval          time.Duration
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func newCertReloader(certFile, keyFile string, interval time.Duration) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile, interval: interval}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *certReloader) load() error {
+	info, err := os.Stat(r.certFile)
+	if err != nil {
+		return fmt.Errorf("failed to stat client certificate: %w", err)
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load client certificate: %w", err)
+	}
+	r.cert = &cert
+	r.modTime = info.ModTime()
+	return nil
+}
+
+func (r *certReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.interval > 0 && time.Since(r.checkedAt) >= r.interval {
+		r.checkedAt = time.Now()
+		if info, err := os.Stat(r.certFile); err == nil && !info.ModTime().Equal(r.modTime) {
+			if err := r.load(); err != nil {
+				slog.Error("Client certificate reload failed, keeping the previous one", "file", r.certFile, "err", err)
+			} else {
+				slog.Info("Client certificate reloaded", "file", r.certFile)
+			}
+		}
+	}
+	return r.cert, nil
+}
@@ -0,0 +1,30 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestWithTransportKeepsInnerMiddleware(t *testing.T) {
+	var got http.Header
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		got = req.Header.Clone()
+		return response(req, http.StatusNoContent, nil), nil
+	})
+	// Middleware installed before and after WithTransport must both stay in the chain
+	client := NewHTTPClient("http://members.example.com", nil, false, false).
+		WithAuth(NewAPIKeyAuth("", "k1")).
+		WithTransport(transport).
+		WithAuth(NewAPIKeyAuth("X-Tenant", "t1"))
+
+	if _, err := Get[struct{}](context.Background(), client, "/members"); err != nil {
+		t.Fatal(err)
+	}
+	if got == nil {
+		t.Fatal("request did not reach the replacement transport")
+	}
+	if got.Get("X-Api-Key") != "k1" || got.Get("X-Tenant") != "t1" {
+		t.Fatalf("headers = %v, want both authenticators applied", got)
+	}
+}
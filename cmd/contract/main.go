@@ -0,0 +1,79 @@
+// Command contract verifies that a live provider honours a consumer-driven
+// contract recorded by the client tests.
+//
+//	go run ./cmd/contract -contract contracts/member-api.json -provider http://localhost:8080 [-header 'Name: value']
+//
+// The contracts under contracts/ are written and checked by the tests in
+// client/http (go test ./client/http -update-contracts). This command
+// replays one against -provider, setting up provider states through the
+// provider's own API.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	http_client "azureclient/client/http"
+)
+
+// providerStates sets up the provider states of each contract through the
+// provider at baseURL
+var providerStates = map[string]func(baseURL string) http_client.ProviderStates{
+	"member-api":  memberStates,
+	"payment-api": paymentStates,
+}
+
+type headerFlags http.Header
+
+func (h headerFlags) String() string { return "" }
+
+func (h headerFlags) Set(v string) error {
+	name, value, ok := strings.Cut(v, ":")
+	if !ok {
+		return fmt.Errorf("header %q is not Name: value", v)
+	}
+	http.Header(h).Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	return nil
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("contract: ")
+
+	path := flag.String("contract", "", "contract file to verify")
+	provider := flag.String("provider", "", "provider base URL")
+	header := headerFlags{}
+	flag.Var(header, "header", "header sent with every request, e.g. 'Authorization: Bearer ...'; repeatable")
+	flag.Parse()
+	if *path == "" || *provider == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := verify(*path, *provider, http.Header(header)); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func verify(path, provider string, header http.Header) error {
+	c, err := http_client.LoadContract(path)
+	if err != nil {
+		return err
+	}
+	v := &http_client.ContractVerifier{BaseURL: provider, Header: header, Client: &http.Client{Timeout: 30 * time.Second}}
+	if states, ok := providerStates[c.Provider]; ok {
+		v.States = states(provider)
+	}
+
+	if err := v.Verify(context.Background(), c); err != nil {
+		return fmt.Errorf("%s does not honour %s:\n%w", c.Provider, path, err)
+	}
+	fmt.Printf("%s honours %d interactions of %s\n", c.Provider, len(c.Interactions), path)
+	return nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	http_client "azureclient/client/http"
+	"azureclient/internal/model"
+)
+
+// Provider states named by the member-api contract
+const (
+	memberExists    = "a member exists"
+	membersExist    = "at least three members exist"
+	memberNotExists = "the member does not exist"
+)
+
+var stateMember = model.Member{Name: "Ada Lovelace", Email: "ada@example.com"}
+
+// memberStates sets up provider states through the member API itself
+func memberStates(baseURL string) http_client.ProviderStates {
+	client := http_client.NewMemberClient(baseURL)
+	return func(ctx context.Context, state string) (map[string]string, error) {
+		switch state {
+		case memberExists, memberNotExists:
+			member, err := client.CreateMember(ctx, stateMember)
+			if err != nil {
+				return nil, err
+			}
+			if state == memberNotExists {
+				if err := client.DeleteMember(ctx, member.ID); err != nil {
+					return nil, err
+				}
+			}
+			return map[string]string{"id": strconv.FormatUint(uint64(member.ID), 10)}, nil
+		case membersExist:
+			for i := 0; i < 3; i++ {
+				if _, err := client.CreateMember(ctx, stateMember); err != nil {
+					return nil, err
+				}
+			}
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unknown provider state %q", state)
+	}
+}
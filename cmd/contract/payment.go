@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	http_client "azureclient/client/http"
+)
+
+// Provider states named by the payment-api contract
+const (
+	intentRequiresCapture = "a payment intent requiring capture exists"
+	intentCaptured        = "a captured payment intent exists"
+	intentNotExists       = "the payment intent does not exist"
+)
+
+// paymentStates sets up provider states through the payment API, which
+// works against a sandbox account
+func paymentStates(baseURL string) http_client.ProviderStates {
+	client := http_client.NewPaymentClient(baseURL)
+	return func(ctx context.Context, state string) (map[string]string, error) {
+		switch state {
+		case intentRequiresCapture, intentCaptured:
+			req := http_client.CreatePaymentIntentRequest{Amount: http_client.NewMoney(1250, "THB"), CaptureMethod: "manual"}
+			if state == intentCaptured {
+				req.CaptureMethod = "automatic"
+			}
+			intent, err := client.CreatePaymentIntent(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]string{"id": intent.ID}, nil
+		case intentNotExists:
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unknown provider state %q", state)
+	}
+}
@@ -0,0 +1,786 @@
+package main
+
+import (
+	"fmt"
+	"go/format"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// generator turns a document into Go source for one client
+type generator struct {
+	doc        *document
+	pkg        string // package of the generated file
+	client     string // client name prefix, e.g. "Member"
+	httpPkg    string // import path of the HTTPClient package
+	httpQual   string // "http_client." or "" when generating into that package
+	specName   string
+	types      []string
+	declared   map[string]bool
+	inline     map[*schema]string // inline schemas already declared, by name
+	methods    []string
+	signatures []string
+}
+
+func newGenerator(doc *document, pkg, client, httpPkg, specName string) *generator {
+	g := &generator{
+		doc:      doc,
+		pkg:      pkg,
+		client:   client,
+		httpPkg:  httpPkg,
+		specName: specName,
+		declared: map[string]bool{},
+		inline:   map[*schema]string{},
+	}
+	if !strings.HasSuffix(httpPkg, "/"+pkg) {
+		g.httpQual = "http_client."
+	}
+	return g
+}
+
+// generate returns the formatted source
+func (g *generator) generate() ([]byte, error) {
+	names := make([]string, 0, len(g.doc.Components.Schemas))
+	for name := range g.doc.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := g.declare(exportName(name), g.doc.Components.Schemas[name]); err != nil {
+			return nil, fmt.Errorf("schema %s: %w", name, err)
+		}
+	}
+
+	paths := make([]string, 0, len(g.doc.Paths))
+	for p := range g.doc.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		item := g.doc.Paths[p]
+		for _, mo := range item.operations() {
+			if err := g.operation(p, mo.method, item, mo.op); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", mo.method, p, err)
+			}
+		}
+	}
+
+	errType, err := g.errorType()
+	if err != nil {
+		return nil, err
+	}
+	var body strings.Builder
+	body.WriteString(g.clientDecl())
+	body.WriteString(errType)
+	for _, m := range g.methods {
+		body.WriteString(m)
+	}
+	for _, t := range g.types {
+		body.WriteString(t)
+	}
+
+	var src strings.Builder
+	fmt.Fprintf(&src, "// Code generated by openapi-gen from %s; DO NOT EDIT.\n\n", g.specName)
+	fmt.Fprintf(&src, "package %s\n\n", g.pkg)
+	src.WriteString(g.imports(body.String()))
+	src.WriteString(body.String())
+
+	out, err := format.Source([]byte(src.String()))
+	if err != nil {
+		return nil, fmt.Errorf("generated code does not parse: %w\n%s", err, src.String())
+	}
+	return out, nil
+}
+
+// imports lists the packages the generated body refers to
+func (g *generator) imports(body string) string {
+	std := []string{"context"}
+	for _, pkg := range []struct{ path, use string }{
+		{"encoding/json", "json."},
+		{"errors", "errors."},
+		{"fmt", "fmt."},
+		{"log/slog", "slog."},
+		{"net/http", "http.Header"},
+		{"strconv", "strconv."},
+		{"time", "time."},
+	} {
+		if strings.Contains(body, pkg.use) {
+			std = append(std, pkg.path)
+		}
+	}
+	slices.Sort(std)
+	var b strings.Builder
+	b.WriteString("import (\n")
+	for _, p := range std {
+		fmt.Fprintf(&b, "\t%q\n", p)
+	}
+	if g.httpQual != "" {
+		fmt.Fprintf(&b, "\n\thttp_client %q\n", g.httpPkg)
+	}
+	b.WriteString(")\n\n")
+	return b.String()
+}
+
+func (g *generator) clientDecl() string {
+	q := g.httpQual
+	var b strings.Builder
+	fmt.Fprintf(&b, "type %sClientInterface interface {\n", g.client)
+	for _, sig := range g.signatures {
+		fmt.Fprintf(&b, "\t%s\n", sig)
+	}
+	b.WriteString("}\n\n")
+	fmt.Fprintf(&b, "type %sClient struct {\n\t*%sHTTPClient\n}\n\n", g.client, q)
+	fmt.Fprintf(&b, `func New%[1]sClient(baseURL string) *%[1]sClient {
+	client := %[2]sNewHTTPClient(baseURL, slog.Default(), false, false).
+		Use(%[2]sWithTracing(), %[2]sWithRetry(%[2]sDefaultRetryPolicy())).
+		WithCircuitBreaker(%[2]sNewCircuitBreaker(%[2]sDefaultCircuitBreakerSettings()))
+	return &%[1]sClient{HTTPClient: client}
+}
+
+`, g.client, q)
+	return b.String()
+}
+
+// errorType declares the client's error type around the error schema most
+// used by the document's non-2xx responses
+func (g *generator) errorType() (string, error) {
+	counts := map[string]int{}
+	for _, item := range g.doc.Paths {
+		for _, mo := range item.operations() {
+			for code, r := range mo.op.Responses {
+				if strings.HasPrefix(code, "2") {
+					continue
+				}
+				r, err := g.doc.response(r)
+				if err != nil {
+					return "", err
+				}
+				if s := jsonSchema(r.Content); s != nil && s.Ref != "" {
+					name, err := refName(s.Ref)
+					if err != nil {
+						return "", err
+					}
+					counts[exportName(name)]++
+				}
+			}
+		}
+	}
+	apiErr := ""
+	for name, n := range counts {
+		if apiErr == "" || n > counts[apiErr] || (n == counts[apiErr] && name < apiErr) {
+			apiErr = name
+		}
+	}
+
+	q := g.httpQual
+	fn := localName(g.client) + "Error"
+	if apiErr == "" {
+		return fmt.Sprintf(`func %s(op string, err error) error {
+	return fmt.Errorf("%%s: %%w", op, err)
+}
+
+`, fn), nil
+	}
+	typ := g.client + "Error"
+	if g.declared[typ] {
+		return "", fmt.Errorf("error type %s collides with a schema name", typ)
+	}
+	return fmt.Sprintf(`// %[1]s is an HTTPError carrying the decoded %[2]s body
+type %[1]s struct {
+	*%[3]sHTTPError
+	API *%[2]s
+}
+
+func (e *%[1]s) Unwrap() error { return e.HTTPError }
+
+// %[4]s unwraps the service's error body when there is one
+func %[4]s(op string, err error) error {
+	var httpErr *%[3]sHTTPError
+	if errors.As(err, &httpErr) {
+		var body %[2]s
+		if httpErr.Decode(&body) == nil {
+			return fmt.Errorf("%%s: %%w", op, &%[1]s{HTTPError: httpErr, API: &body})
+		}
+	}
+	return fmt.Errorf("%%s: %%w", op, err)
+}
+
+`, typ, apiErr, q, fn), nil
+}
+
+// goType returns the Go type for s, declaring named types for inline
+// objects and enums under hint
+func (g *generator) goType(s *schema, hint string) (string, error) {
+	if s == nil {
+		return "any", nil
+	}
+	if s.Ref != "" {
+		name, err := refName(s.Ref)
+		if err != nil {
+			return "", err
+		}
+		if _, ok := g.doc.Components.Schemas[name]; !ok {
+			return "", fmt.Errorf("unknown schema %q", s.Ref)
+		}
+		return exportName(name), nil
+	}
+	if len(s.AllOf) == 1 {
+		return g.goType(s.AllOf[0], hint)
+	}
+	if len(s.AllOf) > 1 || len(s.Properties) > 0 || (len(s.Enum) > 0 && s.Type.Name == "string") {
+		// allOf merges share the inline schemas of their parts
+		if name, ok := g.inline[s]; ok {
+			return name, nil
+		}
+		g.inline[s] = hint
+		return hint, g.declare(hint, s)
+	}
+	if len(s.OneOf) > 0 || len(s.AnyOf) > 0 {
+		return "json.RawMessage", nil
+	}
+	switch s.Type.Name {
+	case "string":
+		switch s.Format {
+		case "date-time":
+			return "time.Time", nil
+		case "byte":
+			return "[]byte", nil
+		}
+		return "string", nil
+	case "integer":
+		if s.Format == "int32" {
+			return "int32", nil
+		}
+		return "int64", nil
+	case "number":
+		if s.Format == "float" {
+			return "float32", nil
+		}
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	case "array":
+		item, err := g.goType(s.Items, hint+"Item")
+		return "[]" + item, err
+	}
+	if add := s.additional(); add != nil {
+		value, err := g.goType(add, hint+"Value")
+		return "map[string]" + value, err
+	}
+	if s.Type.Name == "object" {
+		return "map[string]any", nil
+	}
+	return "any", nil
+}
+
+// isStruct reports whether s becomes a Go struct
+func (g *generator) isStruct(s *schema) bool {
+	if s == nil {
+		return false
+	}
+	if s.Ref != "" {
+		name, err := refName(s.Ref)
+		if err != nil {
+			return false
+		}
+		return g.isStruct(g.doc.Components.Schemas[name])
+	}
+	if len(s.AllOf) == 1 {
+		return g.isStruct(s.AllOf[0])
+	}
+	return len(s.AllOf) > 1 || len(s.Properties) > 0
+}
+
+// merged flattens allOf into a single object schema
+func (g *generator) merged(s *schema) (*schema, error) {
+	if len(s.AllOf) == 0 {
+		return s, nil
+	}
+	out := &schema{Type: schemaType{Name: "object"}, Description: s.Description, Properties: map[string]*schema{}}
+	parts := append([]*schema{{Properties: s.Properties, Required: s.Required}}, s.AllOf...)
+	for _, part := range parts {
+		if part.Ref != "" {
+			name, err := refName(part.Ref)
+			if err != nil {
+				return nil, err
+			}
+			if part = g.doc.Components.Schemas[name]; part == nil {
+				return nil, fmt.Errorf("unknown schema %q", name)
+			}
+		}
+		part, err := g.merged(part)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range part.Properties {
+			out.Properties[k] = v
+		}
+		out.Required = append(out.Required, part.Required...)
+	}
+	return out, nil
+}
+
+// declare emits a named type for s once
+func (g *generator) declare(name string, s *schema) error {
+	if g.declared[name] {
+		return nil
+	}
+	g.declared[name] = true
+	s, err := g.merged(s)
+	if err != nil {
+		return err
+	}
+	// Reserve the slot so the type precedes the inline types it declares
+	slot := len(g.types)
+	g.types = append(g.types, "")
+
+	var b strings.Builder
+	if s.Description != "" {
+		fmt.Fprintf(&b, "// %s %s\n", name, firstLine(s.Description))
+	}
+	switch {
+	case len(s.Properties) > 0 || s.Type.Name == "object" && s.additional() == nil:
+		fmt.Fprintf(&b, "type %s struct {\n", name)
+		props := make([]string, 0, len(s.Properties))
+		for p := range s.Properties {
+			props = append(props, p)
+		}
+		sort.Strings(props)
+		for _, p := range props {
+			prop := s.Properties[p]
+			field := exportName(p)
+			typ, err := g.goType(prop, name+field)
+			if err != nil {
+				return fmt.Errorf("property %s: %w", p, err)
+			}
+			required := s.requires(p)
+			if (!required && (g.isStruct(prop) || typ == "time.Time")) || (prop.nullable() && !strings.HasPrefix(typ, "[]") && !strings.HasPrefix(typ, "map[")) {
+				typ = "*" + typ
+			}
+			tag := p
+			if !required {
+				tag += ",omitempty"
+			}
+			if prop.Description != "" {
+				fmt.Fprintf(&b, "\t// %s\n", firstLine(prop.Description))
+			}
+			fmt.Fprintf(&b, "\t%s %s `json:%q`\n", field, typ, tag)
+		}
+		b.WriteString("}\n\n")
+	case len(s.Enum) > 0 && s.Type.Name == "string":
+		fmt.Fprintf(&b, "type %s string\n\nconst (\n", name)
+		for _, v := range s.Enum {
+			str, ok := v.(string)
+			if !ok {
+				return fmt.Errorf("non-string value %v in string enum", v)
+			}
+			constName := name + exportName(str)
+			if str == "" {
+				constName = name + "Empty"
+			}
+			fmt.Fprintf(&b, "\t%s %s = %q\n", constName, name, str)
+		}
+		b.WriteString(")\n\n")
+	default:
+		typ, err := g.goType(s, name+"Value")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&b, "type %s %s\n\n", name, typ)
+	}
+	g.types[slot] = b.String()
+	return nil
+}
+
+type param struct {
+	name     string // as in the spec
+	in       string
+	goName   string
+	goType   string
+	required bool
+	doc      string
+}
+
+// operation emits the client method for one operation
+func (g *generator) operation(path, method string, item *pathItem, op *operation) error {
+	name := exportName(op.OperationID)
+	if op.OperationID == "" {
+		name = exportName(strings.ToLower(method) + " " + path)
+	}
+
+	// Operation parameters override path-level ones with the same name and location
+	byKey := map[string]*param{}
+	var order []string
+	for _, raw := range append(slices.Clone(item.Parameters), op.Parameters...) {
+		p, err := g.doc.parameter(raw)
+		if err != nil {
+			return err
+		}
+		if p.In == "cookie" {
+			continue
+		}
+		typ, err := g.goType(p.Schema, name+exportName(p.Name))
+		if err != nil {
+			return fmt.Errorf("parameter %s: %w", p.Name, err)
+		}
+		key := p.In + ":" + p.Name
+		if _, ok := byKey[key]; !ok {
+			order = append(order, key)
+		}
+		pp := &param{name: p.Name, in: p.In, goType: typ, required: p.Required || p.In == "path", doc: p.Description}
+		if p.In == "path" {
+			pp.goName = localName(p.Name)
+		} else {
+			pp.goName = exportName(p.Name)
+		}
+		byKey[key] = pp
+	}
+	var pathParams, otherParams []*param
+	for _, key := range order {
+		if p := byKey[key]; p.in == "path" {
+			pathParams = append(pathParams, p)
+		} else {
+			otherParams = append(otherParams, p)
+		}
+	}
+
+	pathExpr, err := g.pathExpr(path, pathParams)
+	if err != nil {
+		return err
+	}
+
+	reqType := ""
+	if op.RequestBody != nil {
+		rb, err := g.doc.requestBody(op.RequestBody)
+		if err != nil {
+			return err
+		}
+		if s := jsonSchema(rb.Content); s != nil {
+			if reqType, err = g.goType(s, name+"Request"); err != nil {
+				return fmt.Errorf("request body: %w", err)
+			}
+		}
+	}
+
+	// The first 2xx response with a JSON body, or else the first 2xx
+	// response, gives the result type and the headers to expose
+	respType, respStruct := "", false
+	var success *response
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		if !strings.HasPrefix(code, "2") {
+			continue
+		}
+		r, err := g.doc.response(op.Responses[code])
+		if err != nil {
+			return err
+		}
+		if success == nil {
+			success = r
+		}
+		if s := jsonSchema(r.Content); s != nil {
+			if respType, err = g.goType(s, name+"Response"); err != nil {
+				return fmt.Errorf("response %s: %w", code, err)
+			}
+			respStruct = g.isStruct(s)
+			success = r
+			break
+		}
+	}
+	var respHeaders []*param
+	if success != nil {
+		if respHeaders, err = g.responseHeaders(name, success); err != nil {
+			return err
+		}
+	}
+
+	q := g.httpQual
+	args := []string{"ctx context.Context"}
+	for _, p := range pathParams {
+		args = append(args, p.goName+" "+p.goType)
+	}
+	paramsType := ""
+	if len(otherParams) > 0 {
+		paramsType = name + "Params"
+		args = append(args, "params "+paramsType)
+		g.declareParams(paramsType, name, otherParams)
+	}
+	if reqType != "" {
+		args = append(args, "body "+reqType)
+	}
+	resultType := ""
+	if len(respHeaders) > 0 {
+		resultType = name + "Result"
+		g.declareResult(resultType, name, respType, respHeaders)
+	}
+	result := "error"
+	switch {
+	case resultType != "":
+		result = fmt.Sprintf("(*%s, error)", resultType)
+	case respType != "" && respStruct:
+		result = fmt.Sprintf("(*%s, error)", respType)
+	case respType != "":
+		result = fmt.Sprintf("(%s, error)", respType)
+	}
+	sig := fmt.Sprintf("%s(%s) %s", name, strings.Join(args, ", "), result)
+	g.signatures = append(g.signatures, sig)
+
+	var b strings.Builder
+	doc := op.Summary
+	if doc == "" {
+		doc = op.Description
+	}
+	if doc != "" {
+		fmt.Fprintf(&b, "// %s %s\n", name, lowerFirst(firstLine(doc)))
+	} else {
+		fmt.Fprintf(&b, "// %s calls %s %s\n", name, method, path)
+	}
+	if op.Deprecated {
+		b.WriteString("//\n// Deprecated: the service marks this operation deprecated.\n")
+	}
+	fmt.Fprintf(&b, "func (c *%sClient) %s {\n", g.client, sig)
+
+	optsArg := ""
+	if resultType != "" {
+		b.WriteString("\tvar header http.Header\n")
+	}
+	if paramsType != "" || resultType != "" {
+		var opts []string
+		if paramsType != "" {
+			opts = append(opts, q+"WithQuery(params)")
+		}
+		if resultType != "" {
+			opts = append(opts, q+"WithResponseHeader(&header)")
+		}
+		fmt.Fprintf(&b, "\topts := []%sRequestOption{%s}\n", q, strings.Join(opts, ", "))
+		for _, p := range otherParams {
+			if p.in != "header" {
+				continue
+			}
+			value := "params." + p.goName
+			cond := zeroCheck(value, p.goType)
+			if p.goType != "string" {
+				value = "fmt.Sprint(" + value + ")"
+			}
+			fmt.Fprintf(&b, "\tif %s {\n\t\topts = append(opts, %sWithHeader(%q, %s))\n\t}\n", cond, q, p.name, value)
+		}
+		optsArg = ", opts..."
+	}
+
+	bodyType, bodyArg := "any", "nil"
+	if reqType != "" {
+		bodyType, bodyArg = reqType, "body"
+	}
+	errFn := localName(g.client) + "Error"
+	opLabel := strings.ToLower(strings.Join(words(name), " "))
+	switch {
+	case resultType != "":
+		out := "_"
+		if respType != "" {
+			out = "out"
+		}
+		doResp := respType
+		if doResp == "" {
+			doResp = "json.RawMessage"
+		}
+		fmt.Fprintf(&b, "\t%s, err := %sDo[%s, %s](ctx, c.HTTPClient, %q, %s, %s%s)\n",
+			out, q, bodyType, doResp, method, pathExpr, bodyArg, optsArg)
+		fmt.Fprintf(&b, "\tif err != nil {\n\t\treturn nil, %s(%q, err)\n\t}\n", errFn, opLabel)
+		if respType != "" {
+			fmt.Fprintf(&b, "\tresult := &%s{Body: out}\n", resultType)
+		} else {
+			fmt.Fprintf(&b, "\tresult := &%s{}\n", resultType)
+		}
+		for _, h := range respHeaders {
+			b.WriteString(parseHeader(h, errFn, opLabel))
+		}
+		b.WriteString("\treturn result, nil\n}\n\n")
+	case respType == "":
+		fmt.Fprintf(&b, "\tif _, err := %sDo[%s, json.RawMessage](ctx, c.HTTPClient, %q, %s, %s%s); err != nil {\n",
+			q, bodyType, method, pathExpr, bodyArg, optsArg)
+		fmt.Fprintf(&b, "\t\treturn %s(%q, err)\n\t}\n\treturn nil\n}\n\n", errFn, opLabel)
+	case respStruct:
+		fmt.Fprintf(&b, "\tout, err := %sDo[%s, %s](ctx, c.HTTPClient, %q, %s, %s%s)\n",
+			q, bodyType, respType, method, pathExpr, bodyArg, optsArg)
+		fmt.Fprintf(&b, "\tif err != nil {\n\t\treturn nil, %s(%q, err)\n\t}\n\treturn &out, nil\n}\n\n", errFn, opLabel)
+	default:
+		fmt.Fprintf(&b, "\tout, err := %sDo[%s, %s](ctx, c.HTTPClient, %q, %s, %s%s)\n",
+			q, bodyType, respType, method, pathExpr, bodyArg, optsArg)
+		fmt.Fprintf(&b, "\tif err != nil {\n\t\treturn out, %s(%q, err)\n\t}\n\treturn out, nil\n}\n\n", errFn, opLabel)
+	}
+	g.methods = append(g.methods, b.String())
+	return nil
+}
+
+// declareParams emits the struct carrying an operation's query and header
+// parameters; query fields are encoded by WithQuery through their url tags
+func (g *generator) declareParams(typ, op string, params []*param) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s holds the query and header parameters of %s\n", typ, op)
+	fmt.Fprintf(&b, "type %s struct {\n", typ)
+	for _, p := range params {
+		if p.doc != "" {
+			fmt.Fprintf(&b, "\t// %s\n", firstLine(p.doc))
+		}
+		tag := fmt.Sprintf(`url:"%s,omitempty"`, p.name)
+		if p.required {
+			tag = fmt.Sprintf(`url:%q`, p.name)
+		}
+		if p.in == "header" {
+			tag = `url:"-"`
+		}
+		fmt.Fprintf(&b, "\t%s %s `%s`\n", p.goName, p.goType, tag)
+	}
+	b.WriteString("}\n\n")
+	g.types = append(g.types, b.String())
+}
+
+// responseHeaders lists the headers declared on an operation's success
+// response, typed so the generated method can parse them
+func (g *generator) responseHeaders(op string, r *response) ([]*param, error) {
+	names := make([]string, 0, len(r.Headers))
+	for name := range r.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var headers []*param
+	for _, name := range names {
+		h, err := g.doc.header(r.Headers[name])
+		if err != nil {
+			return nil, err
+		}
+		typ, err := g.goType(h.Schema, op+exportName(name))
+		if err != nil {
+			return nil, fmt.Errorf("header %s: %w", name, err)
+		}
+		switch typ {
+		case "int32", "int64", "float32", "float64", "bool", "time.Time":
+		default:
+			typ = "string"
+		}
+		headers = append(headers, &param{name: name, in: "header", goName: exportName(name), goType: typ, required: h.Required, doc: h.Description})
+	}
+	return headers, nil
+}
+
+// declareResult emits the struct returned by an operation whose success
+// response declares headers, carrying the body next to those headers
+func (g *generator) declareResult(typ, op, body string, headers []*param) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s holds the body and declared headers of a %s response\n", typ, op)
+	fmt.Fprintf(&b, "type %s struct {\n", typ)
+	if body != "" {
+		fmt.Fprintf(&b, "\tBody %s\n", body)
+	}
+	for _, h := range headers {
+		if h.doc != "" {
+			fmt.Fprintf(&b, "\t// %s\n", firstLine(h.doc))
+		}
+		fmt.Fprintf(&b, "\t%s %s\n", h.goName, h.goType)
+	}
+	b.WriteString("}\n\n")
+	g.types = append(g.types, b.String())
+}
+
+// parseHeader returns the statements copying response header h into
+// result; absent headers leave the zero value
+func parseHeader(h *param, errFn, opLabel string) string {
+	var parse, assign string
+	switch h.goType {
+	case "string":
+		return fmt.Sprintf("\tresult.%s = header.Get(%q)\n", h.goName, h.name)
+	case "int32":
+		parse, assign = "strconv.ParseInt(v, 10, 32)", "int32(parsed)"
+	case "int64":
+		parse, assign = "strconv.ParseInt(v, 10, 64)", "parsed"
+	case "float32":
+		parse, assign = "strconv.ParseFloat(v, 32)", "float32(parsed)"
+	case "float64":
+		parse, assign = "strconv.ParseFloat(v, 64)", "parsed"
+	case "bool":
+		parse, assign = "strconv.ParseBool(v)", "parsed"
+	case "time.Time":
+		parse, assign = "time.Parse(time.RFC3339, v)", "parsed"
+	}
+	return fmt.Sprintf(`	if v := header.Get(%[1]q); v != "" {
+		parsed, err := %[2]s
+		if err != nil {
+			return nil, %[3]s(%[4]q, fmt.Errorf("header %[1]s: %%w", err))
+		}
+		result.%[5]s = %[6]s
+	}
+`, h.name, parse, errFn, opLabel, h.goName, assign)
+}
+
+// pathExpr builds the Go expression for a templated path
+func (g *generator) pathExpr(path string, params []*param) (string, error) {
+	var parts []string
+	rest := path
+	for {
+		open := strings.Index(rest, "{")
+		if open < 0 {
+			break
+		}
+		end := strings.Index(rest[open:], "}")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated parameter in path %s", path)
+		}
+		name := rest[open+1 : open+end]
+		var p *param
+		for _, candidate := range params {
+			if candidate.name == name {
+				p = candidate
+			}
+		}
+		if p == nil {
+			return "", fmt.Errorf("path parameter %s is not declared", name)
+		}
+		if open > 0 {
+			parts = append(parts, strconv.Quote(rest[:open]))
+		}
+		parts = append(parts, fmt.Sprintf("%sPathEscape(%s)", g.httpQual, p.goName))
+		rest = rest[open+end+1:]
+	}
+	if rest != "" || len(parts) == 0 {
+		parts = append(parts, strconv.Quote(rest))
+	}
+	return strings.Join(parts, " + "), nil
+}
+
+func zeroCheck(expr, typ string) string {
+	switch {
+	case strings.HasPrefix(typ, "*"), strings.HasPrefix(typ, "[]"), strings.HasPrefix(typ, "map["):
+		return expr + " != nil"
+	case typ == "bool":
+		return expr
+	case typ == "string":
+		return expr + ` != ""`
+	case strings.HasPrefix(typ, "int"), strings.HasPrefix(typ, "float"):
+		return expr + " != 0"
+	case typ == "time.Time":
+		return "!" + expr + ".IsZero()"
+	}
+	return expr + ` != ""`
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return strings.TrimSuffix(strings.TrimSpace(line), ".")
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	if len(r) > 1 && strings.ToUpper(string(r[:2])) == string(r[:2]) {
+		return s // starts with an acronym
+	}
+	return strings.ToLower(string(r[0])) + string(r[1:])
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+func TestGenerateGolden(t *testing.T) {
+	tests := []struct {
+		spec, pkg, client, golden string
+	}{
+		{spec: "member.json", pkg: "memberapi", client: "Member", golden: "member_client.go.golden"},
+		{spec: "order.json", pkg: "orderapi", client: "Order", golden: "order_client.go.golden"},
+		// Generating into the HTTPClient package itself drops the qualifier
+		{spec: "order.json", pkg: "http", client: "Order", golden: "order_client_http.go.golden"},
+	}
+	for _, tt := range tests {
+		golden := filepath.Join("testdata", tt.golden)
+		t.Run(tt.golden, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "client_gen.go")
+			if err := run(filepath.Join("testdata", tt.spec), out, tt.pkg, tt.client, "azureclient/client/http"); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("generated code differs from %s; rerun with -update and review the diff:\n%s", golden, got)
+			}
+		})
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name, spec, wantErr string
+	}{
+		{name: "swagger 2", spec: `{"swagger": "2.0"}`, wantErr: `unsupported OpenAPI version ""`},
+		{name: "undeclared path parameter", spec: `{"openapi": "3.0.0", "paths": {"/a/{id}": {"get": {"responses": {}}}}}`, wantErr: "path parameter id is not declared"},
+		{name: "unknown schema", spec: `{"openapi": "3.0.0", "paths": {"/a": {"get": {"responses": {"200": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Missing"}}}}}}}}}`, wantErr: "unknown schema"},
+		{name: "unknown header", spec: `{"openapi": "3.0.0", "paths": {"/a": {"get": {"responses": {"204": {"headers": {"X-A": {"$ref": "#/components/headers/Missing"}}}}}}}}`, wantErr: `unknown header "#/components/headers/Missing"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := filepath.Join(t.TempDir(), "openapi.json")
+			if err := os.WriteFile(spec, []byte(tt.spec), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			err := run(spec, filepath.Join(t.TempDir(), "out.go"), "api", "API", "azureclient/client/http")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"go/token"
+	"strings"
+	"unicode"
+)
+
+// initialisms are written in upper case, as in Go naming convention
+var initialisms = map[string]bool{
+	"API": true, "DNS": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true,
+	"JSON": true, "SQL": true, "TLS": true, "TTL": true, "UI": true, "URI": true, "URL": true,
+	"UUID": true, "XML": true,
+}
+
+// words splits an identifier on punctuation and camel case boundaries
+func words(s string) []string {
+	var parts []string
+	for _, field := range strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		runes := []rune(field)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			lowerToUpper := unicode.IsLower(runes[i-1]) && unicode.IsUpper(runes[i])
+			// "HTTPServer" splits before the last capital of a run
+			acronymEnd := i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i+1])
+			if lowerToUpper || acronymEnd {
+				parts = append(parts, string(runes[start:i]))
+				start = i
+			}
+		}
+		parts = append(parts, string(runes[start:]))
+	}
+	return parts
+}
+
+func exportWord(w string) string {
+	if upper := strings.ToUpper(w); initialisms[upper] {
+		return upper
+	}
+	r := []rune(strings.ToLower(w))
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+// exportName converts s to an exported Go identifier
+func exportName(s string) string {
+	var b strings.Builder
+	for _, w := range words(s) {
+		b.WriteString(exportWord(w))
+	}
+	name := b.String()
+	if name == "" {
+		return "X"
+	}
+	if unicode.IsDigit([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
+
+// localName converts s to an unexported Go identifier that is not a keyword
+func localName(s string) string {
+	ws := words(s)
+	if len(ws) == 0 {
+		return "x"
+	}
+	var b strings.Builder
+	b.WriteString(strings.ToLower(ws[0]))
+	for _, w := range ws[1:] {
+		b.WriteString(exportWord(w))
+	}
+	name := b.String()
+	if unicode.IsDigit([]rune(name)[0]) {
+		name = "x" + name
+	}
+	if token.IsKeyword(name) || predeclared[name] {
+		name += "Param"
+	}
+	return name
+}
+
+// predeclared names that would shadow something the generated code uses
+var predeclared = map[string]bool{
+	"ctx": true, "c": true, "err": true, "opts": true, "path": true, "out": true,
+	"body": true, "params": true, "string": true, "error": true, "any": true,
+}
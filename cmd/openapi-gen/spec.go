@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// document is the subset of an OpenAPI 3 document the generator understands
+type document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       info                 `json:"info"`
+	Paths      map[string]*pathItem `json:"paths"`
+	Components components           `json:"components"`
+}
+
+type info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type components struct {
+	Schemas       map[string]*schema      `json:"schemas"`
+	Parameters    map[string]*parameter   `json:"parameters"`
+	RequestBodies map[string]*requestBody `json:"requestBodies"`
+	Responses     map[string]*response    `json:"responses"`
+	Headers       map[string]*header      `json:"headers"`
+}
+
+type pathItem struct {
+	Parameters []*parameter `json:"parameters"`
+	Get        *operation   `json:"get"`
+	Put        *operation   `json:"put"`
+	Post       *operation   `json:"post"`
+	Patch      *operation   `json:"patch"`
+	Delete     *operation   `json:"delete"`
+}
+
+type methodOperation struct {
+	method string
+	op     *operation
+}
+
+// operations returns the item's operations in a stable order
+func (p *pathItem) operations() []methodOperation {
+	var ops []methodOperation
+	for _, m := range []methodOperation{{"GET", p.Get}, {"POST", p.Post}, {"PUT", p.Put}, {"PATCH", p.Patch}, {"DELETE", p.Delete}} {
+		if m.op != nil {
+			ops = append(ops, m)
+		}
+	}
+	return ops
+}
+
+type operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary"`
+	Description string               `json:"description"`
+	Parameters  []*parameter         `json:"parameters"`
+	RequestBody *requestBody         `json:"requestBody"`
+	Responses   map[string]*response `json:"responses"`
+	Deprecated  bool                 `json:"deprecated"`
+}
+
+type parameter struct {
+	Ref         string  `json:"$ref"`
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required"`
+	Description string  `json:"description"`
+	Schema      *schema `json:"schema"`
+}
+
+type requestBody struct {
+	Ref      string                `json:"$ref"`
+	Required bool                  `json:"required"`
+	Content  map[string]*mediaType `json:"content"`
+}
+
+type response struct {
+	Ref         string                `json:"$ref"`
+	Description string                `json:"description"`
+	Headers     map[string]*header    `json:"headers"`
+	Content     map[string]*mediaType `json:"content"`
+}
+
+type header struct {
+	Ref         string  `json:"$ref"`
+	Description string  `json:"description"`
+	Required    bool    `json:"required"`
+	Schema      *schema `json:"schema"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema"`
+}
+
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 schemaType         `json:"type"`
+	Format               string             `json:"format"`
+	Description          string             `json:"description"`
+	Enum                 []any              `json:"enum"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	Items                *schema            `json:"items"`
+	AdditionalProperties json.RawMessage    `json:"additionalProperties"`
+	AllOf                []*schema          `json:"allOf"`
+	OneOf                []*schema          `json:"oneOf"`
+	AnyOf                []*schema          `json:"anyOf"`
+	Nullable             bool               `json:"nullable"`
+}
+
+// schemaType accepts both the 3.0 string form and the 3.1 array form of
+// "type", where "null" in the array marks the schema nullable
+type schemaType struct {
+	Name     string
+	Nullable bool
+}
+
+func (t *schemaType) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &t.Name); err == nil {
+		return nil
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return fmt.Errorf("invalid schema type %s", data)
+	}
+	for _, n := range names {
+		if n == "null" {
+			t.Nullable = true
+		} else if t.Name == "" {
+			t.Name = n
+		}
+	}
+	return nil
+}
+
+func (s *schema) nullable() bool {
+	return s.Nullable || s.Type.Nullable
+}
+
+func (s *schema) requires(prop string) bool {
+	for _, r := range s.Required {
+		if r == prop {
+			return true
+		}
+	}
+	return false
+}
+
+// additional returns the additionalProperties schema, if one is given
+func (s *schema) additional() *schema {
+	if len(s.AdditionalProperties) == 0 || string(s.AdditionalProperties) == "false" {
+		return nil
+	}
+	if string(s.AdditionalProperties) == "true" {
+		return &schema{}
+	}
+	var add schema
+	if json.Unmarshal(s.AdditionalProperties, &add) != nil {
+		return &schema{}
+	}
+	return &add
+}
+
+// refName returns the component name of a local reference
+func refName(ref string) (string, error) {
+	for _, prefix := range []string{
+		"#/components/schemas/", "#/components/parameters/",
+		"#/components/requestBodies/", "#/components/responses/",
+		"#/components/headers/",
+	} {
+		if name, ok := strings.CutPrefix(ref, prefix); ok {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported reference %q, only local component references are supported", ref)
+}
+
+func (d *document) parameter(p *parameter) (*parameter, error) {
+	if p.Ref == "" {
+		return p, nil
+	}
+	name, err := refName(p.Ref)
+	if err != nil {
+		return nil, err
+	}
+	resolved, ok := d.Components.Parameters[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown parameter %q", p.Ref)
+	}
+	return resolved, nil
+}
+
+func (d *document) requestBody(b *requestBody) (*requestBody, error) {
+	if b.Ref == "" {
+		return b, nil
+	}
+	name, err := refName(b.Ref)
+	if err != nil {
+		return nil, err
+	}
+	resolved, ok := d.Components.RequestBodies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown request body %q", b.Ref)
+	}
+	return resolved, nil
+}
+
+func (d *document) response(r *response) (*response, error) {
+	if r.Ref == "" {
+		return r, nil
+	}
+	name, err := refName(r.Ref)
+	if err != nil {
+		return nil, err
+	}
+	resolved, ok := d.Components.Responses[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown response %q", r.Ref)
+	}
+	return resolved, nil
+}
+
+func (d *document) header(h *header) (*header, error) {
+	if h.Ref == "" {
+		return h, nil
+	}
+	name, err := refName(h.Ref)
+	if err != nil {
+		return nil, err
+	}
+	resolved, ok := d.Components.Headers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown header %q", h.Ref)
+	}
+	return resolved, nil
+}
+
+// jsonSchema returns the schema of the JSON media type in content, if any
+func jsonSchema(content map[string]*mediaType) *schema {
+	for ct, mt := range content {
+		if (ct == "application/json" || strings.HasSuffix(ct, "+json")) && mt.Schema != nil {
+			return mt.Schema
+		}
+	}
+	return nil
+}
@@ -3,6 +3,9 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type AzureConfig struct {
@@ -11,9 +14,103 @@ type AzureConfig struct {
 	EmailAccessKey string
 }
 
+// EmailConfig selects the email provider: "acs" (default), "smtp" or "capture"
+type EmailConfig struct {
+	Provider     string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPAuth     string
+	SMTPStartTLS bool
+	DryRun       bool // ACS only, rejected for other providers: validate and build payloads without sending
+	// EventsSecret authenticates Event Grid deliveries to /email/events; the
+	// endpoint is disabled when it is empty
+	EventsSecret string
+	// Audit log body retention; bodies are only stored when AuditStoreBody is set
+	AuditStoreBody         bool
+	AuditBodyRetentionDays int
+	IdempotencyWindow      time.Duration // how long idempotency keys are remembered locally
+	// Batch sending; the defaults match the standard ACS quota
+	RatePerMinute int
+	Burst         int
+	Workers       int
+}
+
+// AuthConfig selects how a downstream client authenticates: "none"
+// (default), "apikey", "bearer", "oauth2" (client credentials) or "azure"
+// (Azure AD via DefaultAzureCredential)
+type AuthConfig struct {
+	Type         string
+	APIKeyHeader string
+	APIKey       string
+	Token        string // static bearer token
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string // oauth2 and azure
+}
+
+// LimitConfig bounds the load a client puts on its downstream; zero values
+// disable the corresponding limit
+type LimitConfig struct {
+	RatePerSecond float64
+	Burst         int
+	MaxInFlight   int
+	FailFast      bool // reject when over the limit instead of waiting
+}
+
+// CacheConfig selects a client's response cache: "none" (default),
+// "memory" (LRU) or "blob" (Azure Blob Storage)
+type CacheConfig struct {
+	Store      string
+	MaxEntries int    // memory only
+	Container  string // blob only
+}
+
+// FaultConfig configures chaos testing of downstream calls. Rules is a JSON
+// array of fault rules; Admin mounts /admin/faults to change them at runtime,
+// which requires AdminToken as a bearer token.
+type FaultConfig struct {
+	Enabled    bool
+	Rules      string
+	Admin      bool
+	AdminToken string
+}
+
+// TransportConfig tunes a client's connections; zero durations and sizes
+// keep the transport defaults
+type TransportConfig struct {
+	CAFile                string
+	CertFile              string
+	KeyFile               string
+	CertReloadInterval    time.Duration
+	ProxyURL              string // empty for the environment, "direct" for none
+	MaxIdleConnsPerHost   int
+	MaxConnsPerHost       int
+	IdleConnTimeout       time.Duration
+	DialTimeout           time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	HTTP2                 bool
+}
+
 type ClientConfig struct {
-	PaymentBaseURL string
-	MemberBaseURL  string
+	PaymentBaseURL          string
+	MemberBaseURL           string
+	PaymentWebhookSecret    string
+	PaymentWebhookTolerance time.Duration // max clock skew accepted on signed webhooks
+	PaymentAuth             AuthConfig
+	MemberAuth              AuthConfig
+	PaymentLimits           LimitConfig
+	MemberLimits            LimitConfig
+	MemberCache             CacheConfig
+	MemberHedging           bool          // hedge slow GETs
+	MemberHedgeDelay        time.Duration // hedge delay until the p95 latency is known
+	MemberSingleflight      bool          // collapse identical concurrent GETs
+	Faults                  FaultConfig
+	PaymentTransport        TransportConfig
+	MemberTransport         TransportConfig
 }
 
 type DBConfig struct {
@@ -25,6 +122,7 @@ type DBConfig struct {
 
 type Config struct {
 	Azure  AzureConfig
+	Email  EmailConfig
 	Client ClientConfig
 	DB     DBConfig
 }
@@ -36,9 +134,49 @@ func LoadConfig() (*Config, error) {
 			EmailEndpoint:  os.Getenv("AZURE_EMAIL_ENDPOINT"),
 			EmailAccessKey: os.Getenv("AZURE_EMAIL_ACCESS_KEY"),
 		},
+		Email: EmailConfig{
+			Provider:               getEnv("EMAIL_PROVIDER", "acs"),
+			SMTPHost:               os.Getenv("SMTP_HOST"),
+			SMTPPort:               getEnvInt("SMTP_PORT", 587),
+			SMTPUsername:           os.Getenv("SMTP_USERNAME"),
+			SMTPPassword:           os.Getenv("SMTP_PASSWORD"),
+			SMTPAuth:               getEnv("SMTP_AUTH", "plain"),
+			SMTPStartTLS:           getEnvBool("SMTP_STARTTLS", true),
+			DryRun:                 getEnvBool("EMAIL_DRY_RUN", false),
+			EventsSecret:           os.Getenv("EMAIL_EVENTS_SECRET"),
+			AuditStoreBody:         getEnvBool("EMAIL_AUDIT_STORE_BODY", false),
+			AuditBodyRetentionDays: getEnvInt("EMAIL_AUDIT_BODY_RETENTION_DAYS", 30),
+			IdempotencyWindow:      getEnvDuration("EMAIL_IDEMPOTENCY_WINDOW", 10*time.Minute),
+			RatePerMinute:          getEnvInt("EMAIL_RATE_PER_MINUTE", 30),
+			Burst:                  getEnvInt("EMAIL_BURST", 5),
+			Workers:                getEnvInt("EMAIL_WORKERS", 4),
+		},
 		Client: ClientConfig{
-			PaymentBaseURL: os.Getenv("PAYMENT_BASE_URL"),
-			MemberBaseURL:  os.Getenv("MEMBER_BASE_URL"),
+			PaymentBaseURL:          os.Getenv("PAYMENT_BASE_URL"),
+			MemberBaseURL:           os.Getenv("MEMBER_BASE_URL"),
+			PaymentWebhookSecret:    os.Getenv("PAYMENT_WEBHOOK_SECRET"),
+			PaymentWebhookTolerance: getEnvDuration("PAYMENT_WEBHOOK_TOLERANCE", 5*time.Minute),
+			PaymentAuth:             loadAuthConfig("PAYMENT"),
+			MemberAuth:              loadAuthConfig("MEMBER"),
+			// The payment provider allows 50 requests per second
+			PaymentLimits: loadLimitConfig("PAYMENT", 50, 20),
+			MemberLimits:  loadLimitConfig("MEMBER", 0, 0),
+			MemberCache: CacheConfig{
+				Store:      getEnv("MEMBER_CACHE", "none"),
+				MaxEntries: getEnvInt("MEMBER_CACHE_MAX_ENTRIES", 1000),
+				Container:  getEnv("MEMBER_CACHE_CONTAINER", "http-cache"),
+			},
+			MemberHedging:      getEnvBool("MEMBER_HEDGING", false),
+			MemberHedgeDelay:   getEnvDuration("MEMBER_HEDGE_DELAY", 200*time.Millisecond),
+			MemberSingleflight: getEnvBool("MEMBER_SINGLEFLIGHT", false),
+			PaymentTransport:   loadTransportConfig("PAYMENT"),
+			MemberTransport:    loadTransportConfig("MEMBER"),
+			Faults: FaultConfig{
+				Enabled:    getEnvBool("FAULT_INJECTION_ENABLED", false),
+				Rules:      os.Getenv("FAULT_INJECTION_RULES"),
+				Admin:      getEnvBool("FAULT_INJECTION_ADMIN", false),
+				AdminToken: os.Getenv("FAULT_INJECTION_ADMIN_TOKEN"),
+			},
 		},
 		DB: DBConfig{
 			User:     os.Getenv("DB_USER"),
@@ -55,6 +193,38 @@ func LoadConfig() (*Config, error) {
 	if cfg.Client.MemberBaseURL == "" {
 		missing = append(missing, "MEMBER_BASE_URL")
 	}
+	// The admin endpoint can break every downstream call, so never expose it unprotected
+	if cfg.Client.Faults.Admin && cfg.Client.Faults.AdminToken == "" {
+		missing = append(missing, "FAULT_INJECTION_ADMIN_TOKEN")
+	}
+	for _, c := range []struct {
+		prefix string
+		auth   AuthConfig
+	}{{"PAYMENT", cfg.Client.PaymentAuth}, {"MEMBER", cfg.Client.MemberAuth}} {
+		m, err := c.auth.missing(c.prefix)
+		if err != nil {
+			return nil, err
+		}
+		missing = append(missing, m...)
+	}
+	switch cfg.Client.MemberCache.Store {
+	case "none", "memory", "blob":
+	default:
+		return nil, fmt.Errorf("unknown MEMBER_CACHE: %s", cfg.Client.MemberCache.Store)
+	}
+	switch cfg.Email.Provider {
+	case "acs", "capture":
+	case "smtp":
+		if cfg.Email.SMTPHost == "" {
+			missing = append(missing, "SMTP_HOST")
+		}
+	default:
+		return nil, fmt.Errorf("unknown EMAIL_PROVIDER: %s", cfg.Email.Provider)
+	}
+	// Other providers would silently send for real
+	if cfg.Email.DryRun && cfg.Email.Provider != "acs" {
+		return nil, fmt.Errorf("EMAIL_DRY_RUN is only supported by the acs provider, not %s", cfg.Email.Provider)
+	}
 	if cfg.DB.User == "" {
 		missing = append(missing, "DB_USER")
 	}
@@ -72,3 +242,115 @@ func LoadConfig() (*Config, error) {
 	}
 	return cfg, nil
 }
+
+// loadAuthConfig reads <prefix>_AUTH and the credentials it needs
+func loadAuthConfig(prefix string) AuthConfig {
+	var scopes []string
+	for _, s := range strings.Split(os.Getenv(prefix+"_AUTH_SCOPES"), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			scopes = append(scopes, s)
+		}
+	}
+	return AuthConfig{
+		Type:         getEnv(prefix+"_AUTH", "none"),
+		APIKeyHeader: getEnv(prefix+"_API_KEY_HEADER", "X-Api-Key"),
+		APIKey:       os.Getenv(prefix + "_API_KEY"),
+		Token:        os.Getenv(prefix + "_AUTH_TOKEN"),
+		TokenURL:     os.Getenv(prefix + "_AUTH_TOKEN_URL"),
+		ClientID:     os.Getenv(prefix + "_AUTH_CLIENT_ID"),
+		ClientSecret: os.Getenv(prefix + "_AUTH_CLIENT_SECRET"),
+		Scopes:       scopes,
+	}
+}
+
+// loadLimitConfig reads <prefix>_RATE_LIMIT_RPS and related limits
+func loadLimitConfig(prefix string, rps float64, maxInFlight int) LimitConfig {
+	return LimitConfig{
+		RatePerSecond: getEnvFloat(prefix+"_RATE_LIMIT_RPS", rps),
+		Burst:         getEnvInt(prefix+"_RATE_LIMIT_BURST", 0),
+		MaxInFlight:   getEnvInt(prefix+"_MAX_IN_FLIGHT", maxInFlight),
+		FailFast:      getEnvBool(prefix+"_RATE_LIMIT_FAIL_FAST", false),
+	}
+}
+
+// loadTransportConfig reads <prefix>_TLS_*, <prefix>_PROXY_URL and the
+// connection pool settings
+func loadTransportConfig(prefix string) TransportConfig {
+	return TransportConfig{
+		CAFile:                os.Getenv(prefix + "_TLS_CA_FILE"),
+		CertFile:              os.Getenv(prefix + "_TLS_CERT_FILE"),
+		KeyFile:               os.Getenv(prefix + "_TLS_KEY_FILE"),
+		CertReloadInterval:    getEnvDuration(prefix+"_TLS_RELOAD_INTERVAL", time.Minute),
+		ProxyURL:              os.Getenv(prefix + "_PROXY_URL"),
+		MaxIdleConnsPerHost:   getEnvInt(prefix+"_MAX_IDLE_CONNS_PER_HOST", 0),
+		MaxConnsPerHost:       getEnvInt(prefix+"_MAX_CONNS_PER_HOST", 0),
+		IdleConnTimeout:       getEnvDuration(prefix+"_IDLE_CONN_TIMEOUT", 0),
+		DialTimeout:           getEnvDuration(prefix+"_DIAL_TIMEOUT", 0),
+		TLSHandshakeTimeout:   getEnvDuration(prefix+"_TLS_HANDSHAKE_TIMEOUT", 0),
+		ResponseHeaderTimeout: getEnvDuration(prefix+"_RESPONSE_HEADER_TIMEOUT", 0),
+		HTTP2:                 getEnvBool(prefix+"_HTTP2", true),
+	}
+}
+
+// missing lists the variables the auth type requires but are unset
+func (a AuthConfig) missing(prefix string) ([]string, error) {
+	var missing []string
+	require := func(value, suffix string) {
+		if value == "" {
+			missing = append(missing, prefix+suffix)
+		}
+	}
+	switch a.Type {
+	case "none":
+	case "apikey":
+		require(a.APIKey, "_API_KEY")
+	case "bearer":
+		require(a.Token, "_AUTH_TOKEN")
+	case "oauth2":
+		require(a.TokenURL, "_AUTH_TOKEN_URL")
+		require(a.ClientID, "_AUTH_CLIENT_ID")
+		require(a.ClientSecret, "_AUTH_CLIENT_SECRET")
+	case "azure":
+		if len(a.Scopes) == 0 {
+			missing = append(missing, prefix+"_AUTH_SCOPES")
+		}
+	default:
+		return nil, fmt.Errorf("unknown %s_AUTH: %s", prefix, a.Type)
+	}
+	return missing, nil
+}
+
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func getEnvInt(key string, fallback int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return v
+	}
+	return fallback
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	if v, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return v
+	}
+	return fallback
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	if v, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return v
+	}
+	return fallback
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if v, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func setRequiredEnv(t *testing.T) {
+	t.Helper()
+	for k, v := range map[string]string{
+		"PAYMENT_BASE_URL": "http://localhost:9000",
+		"MEMBER_BASE_URL":  "http://localhost:9001",
+		"DB_USER":          "user",
+		"DB_PASSWORD":      "password",
+		"DB_HOST":          "localhost",
+		"DB_NAME":          "azureclient",
+	} {
+		t.Setenv(k, v)
+	}
+}
+
+func TestLoadConfigFaultAdminRequiresToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		admin   string
+		token   string
+		wantErr bool
+	}{
+		{name: "admin disabled", admin: "false"},
+		{name: "admin with token", admin: "true", token: "s3cret"},
+		{name: "admin without token", admin: "true", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequiredEnv(t)
+			t.Setenv("FAULT_INJECTION_ADMIN", tt.admin)
+			t.Setenv("FAULT_INJECTION_ADMIN_TOKEN", tt.token)
+			_, err := LoadConfig()
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "FAULT_INJECTION_ADMIN_TOKEN") {
+				t.Fatalf("err = %v, want it to name FAULT_INJECTION_ADMIN_TOKEN", err)
+			}
+		})
+	}
+}
+
+func TestLoadConfigDryRunRequiresACS(t *testing.T) {
+	tests := []struct {
+		provider string
+		wantErr  bool
+	}{
+		{provider: "acs"},
+		{provider: "capture", wantErr: true},
+		{provider: "smtp", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			setRequiredEnv(t)
+			t.Setenv("EMAIL_PROVIDER", tt.provider)
+			t.Setenv("SMTP_HOST", "localhost")
+			t.Setenv("EMAIL_DRY_RUN", "true")
+			_, err := LoadConfig()
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "EMAIL_DRY_RUN") {
+				t.Fatalf("err = %v, want it to name EMAIL_DRY_RUN", err)
+			}
+		})
+	}
+}
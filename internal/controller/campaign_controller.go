@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"azureclient/client/azure"
+	"azureclient/internal/middleware"
+	"azureclient/internal/service"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type campaignRequest struct {
+	Sender   string              `json:"sender"`
+	Template azure.EmailTemplate `json:"template"`
+}
+
+type CampaignController struct {
+	Service service.CampaignService
+}
+
+func NewCampaignController(s service.CampaignService) *CampaignController {
+	return &CampaignController{Service: s}
+}
+
+func (c *CampaignController) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/email/campaigns", middleware.ErrorHandler(c.StartCampaign)).Methods("POST")
+	r.HandleFunc("/email/campaigns/{id}", middleware.ErrorHandler(c.GetCampaign)).Methods("GET")
+	r.HandleFunc("/email/campaigns/{id}/results", middleware.ErrorHandler(c.ListCampaignResults)).Methods("GET")
+	r.HandleFunc("/email/campaigns/{id}/resume", middleware.ErrorHandler(c.ResumeCampaign)).Methods("POST")
+	r.HandleFunc("/email/campaigns/{id}/cancel", middleware.ErrorHandler(c.CancelCampaign)).Methods("POST")
+}
+
+// StartCampaign accepts the campaign and sends it in the background; poll
+// the Location for progress
+func (c *CampaignController) StartCampaign(w http.ResponseWriter, r *http.Request) error {
+	var req campaignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil
+	}
+	campaign, err := c.Service.StartCampaign(r.Context(), req.Sender, req.Template)
+	if err != nil {
+		return err
+	}
+	writeCampaignAccepted(w, campaign)
+	return nil
+}
+
+func (c *CampaignController) GetCampaign(w http.ResponseWriter, r *http.Request) error {
+	campaign, err := c.Service.GetCampaign(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(campaign)
+	return nil
+}
+
+// ListCampaignResults lists the per-recipient results, filtered by the
+// optional status query parameter
+func (c *CampaignController) ListCampaignResults(w http.ResponseWriter, r *http.Request) error {
+	results, err := c.Service.ListCampaignResults(r.Context(), mux.Vars(r)["id"], r.URL.Query().Get("status"))
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+	return nil
+}
+
+// ResumeCampaign restarts an interrupted campaign, skipping recipients
+// already sent
+func (c *CampaignController) ResumeCampaign(w http.ResponseWriter, r *http.Request) error {
+	campaign, err := c.Service.ResumeCampaign(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		return err
+	}
+	writeCampaignAccepted(w, campaign)
+	return nil
+}
+
+// CancelCampaign stops a running campaign and returns it once its progress is saved
+func (c *CampaignController) CancelCampaign(w http.ResponseWriter, r *http.Request) error {
+	campaign, err := c.Service.CancelCampaign(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(campaign)
+	return nil
+}
+
+func writeCampaignAccepted(w http.ResponseWriter, campaign *service.Campaign) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/email/campaigns/"+campaign.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(campaign)
+}
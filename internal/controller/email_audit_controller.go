@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"azureclient/internal/middleware"
+	"azureclient/internal/model"
+	"azureclient/internal/service"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type EmailAuditController struct {
+	Service service.EmailAuditService
+}
+
+func NewEmailAuditController(s service.EmailAuditService) *EmailAuditController {
+	return &EmailAuditController{Service: s}
+}
+
+func (c *EmailAuditController) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/email/audit", middleware.ErrorHandler(c.ListAudits)).Methods("GET")
+	r.HandleFunc("/members/{id}/emails", middleware.ErrorHandler(c.ListMemberAudits)).Methods("GET")
+}
+
+// ListAudits supports ?recipient=, ?status=, ?from=, ?to= (RFC 3339), ?limit= and ?offset=
+func (c *EmailAuditController) ListAudits(w http.ResponseWriter, r *http.Request) error {
+	filter, ok := parseAuditFilter(w, r)
+	if !ok {
+		return nil
+	}
+	audits, err := c.Service.ListAudits(r.Context(), 0, filter)
+	if err != nil {
+		return err
+	}
+	json.NewEncoder(w).Encode(audits)
+	return nil
+}
+
+func (c *EmailAuditController) ListMemberAudits(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return nil
+	}
+	filter, ok := parseAuditFilter(w, r)
+	if !ok {
+		return nil
+	}
+	audits, err := c.Service.ListAudits(r.Context(), uint(id), filter)
+	if err != nil {
+		return err
+	}
+	json.NewEncoder(w).Encode(audits)
+	return nil
+}
+
+func parseAuditFilter(w http.ResponseWriter, r *http.Request) (model.EmailAuditFilter, bool) {
+	q := r.URL.Query()
+	filter := model.EmailAuditFilter{
+		Recipient: q.Get("recipient"),
+		Status:    q.Get("status"),
+		Limit:     100,
+	}
+	for key, dst := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if v := q.Get(key); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				http.Error(w, "Invalid "+key, http.StatusBadRequest)
+				return filter, false
+			}
+			*dst = t
+		}
+	}
+	for key, dst := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		if v := q.Get(key); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				http.Error(w, "Invalid "+key, http.StatusBadRequest)
+				return filter, false
+			}
+			*dst = n
+		}
+	}
+	return filter, true
+}
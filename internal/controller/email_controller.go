@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"azureclient/client/azure"
+	"azureclient/internal/middleware"
+	"azureclient/internal/service"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type previewRequest struct {
+	Template  azure.EmailTemplate `json:"template"`
+	Data      map[string]any      `json:"data"`
+	Sender    string              `json:"sender,omitempty"`
+	Recipient string              `json:"recipient,omitempty"`
+}
+
+type EmailController struct {
+	Service service.EmailService
+}
+
+func NewEmailController(s service.EmailService) *EmailController {
+	return &EmailController{Service: s}
+}
+
+func (c *EmailController) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/email/preview", middleware.ErrorHandler(c.Preview)).Methods("POST")
+}
+
+// Preview renders a template with sample data. ?format=html or ?format=text
+// return the rendered body as-is for viewing in a browser; the default is
+// JSON including the ACS payload when sender and recipient are supplied.
+func (c *EmailController) Preview(w http.ResponseWriter, r *http.Request) error {
+	var req previewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil
+	}
+	preview, err := c.Service.PreviewEmail(r.Context(), req.Template, req.Data, req.Sender, req.Recipient)
+	if err != nil {
+		return err
+	}
+	switch r.URL.Query().Get("format") {
+	case "html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(preview.Rendered.HTML))
+	case "text":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(preview.Rendered.PlainText))
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(preview)
+	}
+	return nil
+}
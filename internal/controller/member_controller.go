@@ -21,7 +21,7 @@ func NewMemberController(s service.MemberService) *MemberController {
 
 func (c *MemberController) RegisterRoutes(r *mux.Router) {
 	r.HandleFunc("/members", middleware.ErrorHandler(c.CreateMember)).Methods("POST")
-	r.HandleFunc("/members/{id}", middleware.ErrorHandler(c.GetMemberByID)).Methods("GET"))
+	r.HandleFunc("/members/{id}", middleware.ErrorHandler(c.GetMemberByID)).Methods("GET")
 	r.HandleFunc("/members/{id}", middleware.ErrorHandler(c.UpdateMember)).Methods("PUT")
 	r.HandleFunc("/members/{id}", middleware.ErrorHandler(c.DeleteMember)).Methods("DELETE")
 	r.HandleFunc("/members", middleware.ErrorHandler(c.ListMembers)).Methods("GET")
@@ -36,6 +36,7 @@ func (c *MemberController) CreateMember(w http.ResponseWriter, r *http.Request)
 	if err := c.Service.CreateMember(r.Context(), &member); err != nil {
 		return err
 	}
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(member)
 	return nil
@@ -52,6 +53,7 @@ func (c *MemberController) GetMemberByID(w http.ResponseWriter, r *http.Request)
 	if err != nil {
 		return err
 	}
+	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(member)
 	return nil
 }
@@ -72,6 +74,7 @@ func (c *MemberController) UpdateMember(w http.ResponseWriter, r *http.Request)
 	if err := c.Service.UpdateMember(r.Context(), &member); err != nil {
 		return err
 	}
+	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(member)
 	return nil
 }
@@ -90,11 +93,42 @@ func (c *MemberController) DeleteMember(w http.ResponseWriter, r *http.Request)
 	return nil
 }
 
+// ListMembers returns every member, or one page when ?limit= is given.
+// Paged responses carry the cursor for ?after= in the X-Next-Cursor header.
 func (c *MemberController) ListMembers(w http.ResponseWriter, r *http.Request) error {
-	members, err := c.Service.ListMembers(r.Context())
+	q := r.URL.Query()
+	if q.Get("limit") == "" {
+		members, err := c.Service.ListMembers(r.Context())
+		if err != nil {
+			return err
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(members)
+		return nil
+	}
+	limit, err := strconv.Atoi(q.Get("limit"))
+	if err != nil || limit < 1 || limit > 1000 {
+		http.Error(w, "Invalid limit", http.StatusBadRequest)
+		return nil
+	}
+	var after uint64
+	if v := q.Get("after"); v != "" {
+		if after, err = strconv.ParseUint(v, 10, 64); err != nil {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return nil
+		}
+	}
+	members, next, err := c.Service.ListMembersPage(r.Context(), uint(after), limit)
 	if err != nil {
 		return err
 	}
+	if next != 0 {
+		w.Header().Set("X-Next-Cursor", strconv.FormatUint(uint64(next), 10))
+	}
+	if members == nil {
+		members = []model.Member{}
+	}
+	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(members)
 	return nil
 }
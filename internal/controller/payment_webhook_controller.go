@@ -0,0 +1,54 @@
+package controller
+
+import (
+	http_client "azureclient/client/http"
+	"azureclient/internal/errs"
+	"azureclient/internal/middleware"
+	"azureclient/internal/service"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// maxWebhookBody bounds the size of an accepted webhook payload
+const maxWebhookBody = 1 << 20
+
+type PaymentWebhookController struct {
+	Service   service.PaymentEventService
+	Secret    []byte
+	Tolerance time.Duration
+}
+
+func NewPaymentWebhookController(s service.PaymentEventService, secret string, tolerance time.Duration) *PaymentWebhookController {
+	return &PaymentWebhookController{Service: s, Secret: []byte(secret), Tolerance: tolerance}
+}
+
+// RegisterRoutes registers the webhook only when a secret is configured, since
+// every delivery would otherwise fail verification
+func (c *PaymentWebhookController) RegisterRoutes(r *mux.Router) {
+	if len(c.Secret) > 0 {
+		r.HandleFunc("/webhooks/payment", middleware.ErrorHandler(c.ReceiveEvent)).Methods("POST")
+	}
+}
+
+func (c *PaymentWebhookController) ReceiveEvent(w http.ResponseWriter, r *http.Request) error {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody+1))
+	if err != nil {
+		return errs.BadRequest
+	}
+	if len(body) > maxWebhookBody {
+		http.Error(w, "payload too large", http.StatusRequestEntityTooLarge)
+		return nil
+	}
+	event, err := http_client.VerifyPaymentWebhook(c.Secret, r.Header.Get(http_client.PaymentSignatureHeader), body, c.Tolerance, time.Now())
+	if err != nil {
+		return &errs.AppError{Status: errs.Unauthorized.Status, Message: err.Error(), Code: errs.Unauthorized.Code}
+	}
+	if err := c.Service.HandleEvent(r.Context(), event); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
@@ -0,0 +1,57 @@
+package controller
+
+import (
+	http_client "azureclient/client/http"
+	"azureclient/internal/service"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type fakePaymentEventService struct {
+	service.PaymentEventService
+	events []*http_client.PaymentEvent
+}
+
+func (f *fakePaymentEventService) HandleEvent(ctx context.Context, event *http_client.PaymentEvent) error {
+	f.events = append(f.events, event)
+	return nil
+}
+
+func TestReceivePaymentEvent(t *testing.T) {
+	body := []byte(`{"id":"evt_1","type":"payment_intent.succeeded","data":{}}`)
+	ts := fmt.Sprint(time.Now().Unix())
+	tests := []struct {
+		name      string
+		secret    string // configured
+		signature string
+		want      int
+	}{
+		{name: "signed", secret: "whsec", signature: http_client.SignPaymentWebhook([]byte("whsec"), ts, body), want: http.StatusNoContent},
+		{name: "wrong signature", secret: "whsec", signature: http_client.SignPaymentWebhook([]byte("guess"), ts, body), want: http.StatusUnauthorized},
+		{name: "unconfigured", want: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &fakePaymentEventService{}
+			r := mux.NewRouter()
+			NewPaymentWebhookController(svc, tt.secret, 5*time.Minute).RegisterRoutes(r)
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/payment", bytes.NewReader(body))
+			req.Header.Set(http_client.PaymentSignatureHeader, "t="+ts+",v1="+tt.signature)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if accepted := tt.want == http.StatusNoContent; accepted != (len(svc.events) == 1) {
+				t.Fatalf("service got %d events, accepted %v", len(svc.events), accepted)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"azureclient/internal/errs"
+	"azureclient/internal/middleware"
+	"azureclient/internal/model"
+	"azureclient/internal/service"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// EventsSecretHeader carries the shared secret of /email/events; configure
+// it as a delivery attribute on the Event Grid subscription, or pass the
+// secret as ?code= in the endpoint URL
+const EventsSecretHeader = "X-Events-Secret"
+
+const (
+	eventTypeSubscriptionValidation = "Microsoft.EventGrid.SubscriptionValidationEvent"
+	eventTypeEmailDeliveryReport    = "Microsoft.Communication.EmailDeliveryReportReceived"
+)
+
+// eventGridEvent is an event in the Event Grid schema
+type eventGridEvent struct {
+	ID        string          `json:"id"`
+	EventType string          `json:"eventType"`
+	Subject   string          `json:"subject"`
+	EventTime time.Time       `json:"eventTime"`
+	Data      json.RawMessage `json:"data"`
+}
+
+type emailDeliveryReportData struct {
+	Sender                string `json:"sender"`
+	Recipient             string `json:"recipient"`
+	MessageID             string `json:"messageId"`
+	Status                string `json:"status"`
+	DeliveryStatusDetails struct {
+		StatusMessage string `json:"statusMessage"`
+	} `json:"deliveryStatusDetails"`
+}
+
+type SuppressionController struct {
+	Service      service.SuppressionService
+	EventsSecret []byte
+}
+
+func NewSuppressionController(s service.SuppressionService, eventsSecret string) *SuppressionController {
+	return &SuppressionController{Service: s, EventsSecret: []byte(eventsSecret)}
+}
+
+// RegisterRoutes registers the suppression API, and /email/events only when
+// an events secret is configured
+func (c *SuppressionController) RegisterRoutes(r *mux.Router) {
+	if len(c.EventsSecret) > 0 {
+		r.HandleFunc("/email/events", middleware.ErrorHandler(c.ReceiveEvents)).Methods("POST")
+	}
+	r.HandleFunc("/email/suppressions", middleware.ErrorHandler(c.AddSuppression)).Methods("POST")
+	r.HandleFunc("/email/suppressions", middleware.ErrorHandler(c.ListSuppressions)).Methods("GET")
+	r.HandleFunc("/email/suppressions/{email}", middleware.ErrorHandler(c.GetSuppression)).Methods("GET")
+	r.HandleFunc("/email/suppressions/{email}", middleware.ErrorHandler(c.RemoveSuppression)).Methods("DELETE")
+}
+
+// ReceiveEvents handles Event Grid deliveries, answering the subscription
+// validation handshake and applying delivery reports to the suppression list.
+// Requests without the shared secret are rejected.
+func (c *SuppressionController) ReceiveEvents(w http.ResponseWriter, r *http.Request) error {
+	if !c.authorized(r) {
+		return errs.Unauthorized
+	}
+	var events []eventGridEvent
+	if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil
+	}
+	for _, event := range events {
+		switch event.EventType {
+		case eventTypeSubscriptionValidation:
+			var data struct {
+				ValidationCode string `json:"validationCode"`
+			}
+			if err := json.Unmarshal(event.Data, &data); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return nil
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]string{"validationResponse": data.ValidationCode})
+			return nil
+		case eventTypeEmailDeliveryReport:
+			var data emailDeliveryReportData
+			if err := json.Unmarshal(event.Data, &data); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return nil
+			}
+			err := c.Service.HandleDeliveryReport(r.Context(), service.DeliveryReport{
+				MessageID:     data.MessageID,
+				Recipient:     data.Recipient,
+				Status:        data.Status,
+				StatusMessage: data.DeliveryStatusDetails.StatusMessage,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// authorized checks the events secret from the header or the code query
+// parameter; an unconfigured secret rejects everything
+func (c *SuppressionController) authorized(r *http.Request) bool {
+	if len(c.EventsSecret) == 0 {
+		return false
+	}
+	got := r.Header.Get(EventsSecretHeader)
+	if got == "" {
+		got = r.URL.Query().Get("code")
+	}
+	return subtle.ConstantTimeCompare([]byte(got), c.EventsSecret) == 1
+}
+
+func (c *SuppressionController) AddSuppression(w http.ResponseWriter, r *http.Request) error {
+	var sup model.EmailSuppression
+	if err := json.NewDecoder(r.Body).Decode(&sup); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil
+	}
+	if err := c.Service.AddSuppression(r.Context(), &sup); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(sup)
+	return nil
+}
+
+func (c *SuppressionController) GetSuppression(w http.ResponseWriter, r *http.Request) error {
+	sup, err := c.Service.GetSuppression(r.Context(), mux.Vars(r)["email"])
+	if err != nil {
+		return err
+	}
+	json.NewEncoder(w).Encode(sup)
+	return nil
+}
+
+func (c *SuppressionController) ListSuppressions(w http.ResponseWriter, r *http.Request) error {
+	list, err := c.Service.ListSuppressions(r.Context())
+	if err != nil {
+		return err
+	}
+	json.NewEncoder(w).Encode(list)
+	return nil
+}
+
+func (c *SuppressionController) RemoveSuppression(w http.ResponseWriter, r *http.Request) error {
+	if err := c.Service.RemoveSuppression(r.Context(), mux.Vars(r)["email"]); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
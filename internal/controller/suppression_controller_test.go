@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"azureclient/internal/model"
+	"azureclient/internal/service"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+type fakeSuppressionService struct {
+	service.SuppressionService
+	reports []service.DeliveryReport
+}
+
+func (f *fakeSuppressionService) HandleDeliveryReport(ctx context.Context, report service.DeliveryReport) error {
+	f.reports = append(f.reports, report)
+	return nil
+}
+
+func (f *fakeSuppressionService) ListSuppressions(ctx context.Context) ([]model.EmailSuppression, error) {
+	return nil, nil
+}
+
+func TestReceiveEventsRequiresSecret(t *testing.T) {
+	events, err := os.ReadFile("testdata/email_delivery_events.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		secret string // configured
+		header string
+		query  string
+		want   int
+	}{
+		{name: "header", secret: "s3cret", header: "s3cret", want: http.StatusOK},
+		{name: "query code", secret: "s3cret", query: "?code=s3cret", want: http.StatusOK},
+		{name: "missing", secret: "s3cret", want: http.StatusUnauthorized},
+		{name: "wrong", secret: "s3cret", header: "guess", want: http.StatusUnauthorized},
+		{name: "unconfigured", want: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &fakeSuppressionService{}
+			r := mux.NewRouter()
+			NewSuppressionController(svc, tt.secret).RegisterRoutes(r)
+			req := httptest.NewRequest(http.MethodPost, "/email/events"+tt.query, bytes.NewReader(events))
+			if tt.header != "" {
+				req.Header.Set(EventsSecretHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want != http.StatusOK && len(svc.reports) > 0 {
+				t.Fatalf("rejected request reached the service: %+v", svc.reports)
+			}
+			if tt.want == http.StatusOK && len(svc.reports) != 2 {
+				t.Fatalf("got %d reports, want 2", len(svc.reports))
+			}
+		})
+	}
+}
+
+func TestReceiveEventsAnswersValidation(t *testing.T) {
+	body := `[{"id":"1","eventType":"Microsoft.EventGrid.SubscriptionValidationEvent","data":{"validationCode":"abc"}}]`
+	r := mux.NewRouter()
+	NewSuppressionController(&fakeSuppressionService{}, "s3cret").RegisterRoutes(r)
+	req := httptest.NewRequest(http.MethodPost, "/email/events?code=s3cret", bytes.NewBufferString(body))
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !bytes.Contains(rec.Body.Bytes(), []byte(`"validationResponse":"abc"`)) {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+}
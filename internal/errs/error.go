@@ -16,8 +16,15 @@ func (e *AppError) Error() string {
 }
 
 var (
-	UnableToProceed = &AppError{Status: 5000, Message: "error unable to proceed", Code: http.StatusBadRequest}
-	BadRequest      = &AppError{Status: 4000, Message: "bad request", Code: http.StatusBadRequest}
-	NotFound        = &AppError{Status: 4040, Message: "not found", Code: http.StatusNotFound}
+	UnableToProceed    = &AppError{Status: 5000, Message: "error unable to proceed", Code: http.StatusBadRequest}
+	BadRequest         = &AppError{Status: 4000, Message: "bad request", Code: http.StatusBadRequest}
+	Unauthorized       = &AppError{Status: 4010, Message: "unauthorized", Code: http.StatusUnauthorized}
+	NotFound           = &AppError{Status: 4040, Message: "not found", Code: http.StatusNotFound}
+	TooManyRequests    = &AppError{Status: 4290, Message: "too many requests", Code: http.StatusTooManyRequests}
+	EmailFailed        = &AppError{Status: 5020, Message: "email delivery failed", Code: http.StatusBadGateway}
+	EmailSuppressed    = &AppError{Status: 4221, Message: "recipient is on the suppression list", Code: http.StatusUnprocessableEntity}
+	CampaignRunning    = &AppError{Status: 4090, Message: "campaign is already running", Code: http.StatusConflict}
+	CampaignNotRunning = &AppError{Status: 4091, Message: "campaign is not running", Code: http.StatusConflict}
+	CampaignCancelled  = &AppError{Status: 4092, Message: "campaign was cancelled", Code: http.StatusConflict}
 	// Add more custom errors here as needed
 )
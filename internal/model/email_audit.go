@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// EmailAudit records one attempt to send an email
+type EmailAudit struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	RequestID    string    `gorm:"size:64;index" json:"requestId"`
+	OperationID  string    `gorm:"size:64" json:"operationId,omitempty"`
+	Sender       string    `gorm:"size:320" json:"sender"`
+	Recipient    string    `gorm:"size:320;index" json:"recipient"`
+	Subject      string    `json:"subject"`
+	TemplateName string    `gorm:"size:128" json:"templateName,omitempty"`
+	Status       string    `gorm:"size:32;index" json:"status"`
+	Error        string    `json:"error,omitempty"`
+	PlainText    string    `gorm:"type:text" json:"plainText,omitempty"`
+	HTML         string    `gorm:"type:mediumtext" json:"html,omitempty"`
+	DurationMs   int64     `json:"durationMs"`
+	CreatedAt    time.Time `gorm:"index" json:"createdAt"`
+}
+
+// EmailAuditFilter narrows an audit query; zero values are ignored
+type EmailAuditFilter struct {
+	Recipient string
+	Status    string
+	From      time.Time
+	To        time.Time
+	Limit     int
+	Offset    int
+}
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// Campaign statuses
+const (
+	CampaignRunning     = "running"
+	CampaignCompleted   = "completed"
+	CampaignFailed      = "failed"
+	CampaignCancelled   = "cancelled"
+	CampaignInterrupted = "interrupted" // stopped by a shutdown; can be resumed
+)
+
+// EmailCampaign persists a batch campaign so its progress outlives the
+// request that started it and an interrupted run can be resumed
+type EmailCampaign struct {
+	ID        string    `gorm:"primaryKey;size:36" json:"id"`
+	Sender    string    `gorm:"size:320" json:"sender"`
+	Template  string    `gorm:"type:text" json:"-"`     // azure.EmailTemplate as JSON
+	State     string    `gorm:"type:longtext" json:"-"` // azure.CampaignState as JSON
+	Status    string    `gorm:"size:16" json:"status"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// Suppression reasons
+const (
+	SuppressionHardBounce = "hard_bounce"
+	SuppressionComplaint  = "complaint" // added manually; ACS does not report complaints
+	SuppressionManual     = "manual"
+)
+
+// EmailSuppression is an address that must not receive email
+type EmailSuppression struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Email     string    `gorm:"uniqueIndex;size:320" json:"email"`
+	Reason    string    `gorm:"size:32" json:"reason"`
+	Detail    string    `json:"detail,omitempty"`
+	MessageID string    `gorm:"size:64" json:"messageId,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
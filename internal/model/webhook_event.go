@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// WebhookEvent records an incoming webhook event so redeliveries are processed once
+type WebhookEvent struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	Source     string    `gorm:"size:32;uniqueIndex:idx_webhook_source_event" json:"source"`
+	EventID    string    `gorm:"size:128;uniqueIndex:idx_webhook_source_event" json:"eventId"`
+	Type       string    `gorm:"size:64" json:"type"`
+	ReceivedAt time.Time `gorm:"autoCreateTime" json:"receivedAt"`
+}
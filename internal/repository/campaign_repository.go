@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"azureclient/internal/model"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type CampaignRepository interface {
+	// Save creates or updates the campaign
+	Save(ctx context.Context, campaign *model.EmailCampaign) error
+	Get(ctx context.Context, id string) (*model.EmailCampaign, error)
+	ListByStatus(ctx context.Context, status string) ([]model.EmailCampaign, error)
+}
+
+type campaignRepository struct {
+	db *gorm.DB
+}
+
+func NewCampaignRepository(db *gorm.DB) CampaignRepository {
+	return &campaignRepository{db: db}
+}
+
+func (r *campaignRepository) Save(ctx context.Context, campaign *model.EmailCampaign) error {
+	return r.db.WithContext(ctx).Save(campaign).Error
+}
+
+func (r *campaignRepository) Get(ctx context.Context, id string) (*model.EmailCampaign, error) {
+	var campaign model.EmailCampaign
+	if err := r.db.WithContext(ctx).First(&campaign, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &campaign, nil
+}
+
+func (r *campaignRepository) ListByStatus(ctx context.Context, status string) ([]model.EmailCampaign, error) {
+	var campaigns []model.EmailCampaign
+	if err := r.db.WithContext(ctx).Where("status = ?", status).Find(&campaigns).Error; err != nil {
+		return nil, err
+	}
+	return campaigns, nil
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"azureclient/internal/model"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type EmailAuditRepository interface {
+	Create(ctx context.Context, audit *model.EmailAudit) error
+	List(ctx context.Context, filter model.EmailAuditFilter) ([]model.EmailAudit, error)
+	PurgeBodies(ctx context.Context, before time.Time) (int64, error)
+}
+
+type emailAuditRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailAuditRepository(db *gorm.DB) EmailAuditRepository {
+	return &emailAuditRepository{db: db}
+}
+
+func (r *emailAuditRepository) Create(ctx context.Context, audit *model.EmailAudit) error {
+	return r.db.WithContext(ctx).Create(audit).Error
+}
+
+func (r *emailAuditRepository) List(ctx context.Context, filter model.EmailAuditFilter) ([]model.EmailAudit, error) {
+	q := r.db.WithContext(ctx).Order("created_at desc")
+	if filter.Recipient != "" {
+		q = q.Where("recipient = ?", normalizeEmail(filter.Recipient))
+	}
+	if filter.Status != "" {
+		q = q.Where("status = ?", filter.Status)
+	}
+	if !filter.From.IsZero() {
+		q = q.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		q = q.Where("created_at < ?", filter.To)
+	}
+	if filter.Limit > 0 {
+		q = q.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		q = q.Offset(filter.Offset)
+	}
+	var audits []model.EmailAudit
+	err := q.Find(&audits).Error
+	return audits, err
+}
+
+// PurgeBodies clears stored bodies older than before, keeping the metadata
+func (r *emailAuditRepository) PurgeBodies(ctx context.Context, before time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&model.EmailAudit{}).
+		Where("created_at < ? AND (plain_text <> '' OR html <> '')", before).
+		Updates(map[string]interface{}{"plain_text": "", "html": ""})
+	return res.RowsAffected, res.Error
+}
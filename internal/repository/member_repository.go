@@ -13,6 +13,7 @@ type MemberRepository interface {
 	Update(ctx context.Context, member *model.Member) error
 	Delete(ctx context.Context, id uint) error
 	List(ctx context.Context) ([]model.Member, error)
+	ListPage(ctx context.Context, afterID uint, limit int) ([]model.Member, error)
 }
 
 type memberRepository struct {
@@ -49,3 +50,10 @@ func (r *memberRepository) List(ctx context.Context) ([]model.Member, error) {
 	err := r.db.WithContext(ctx).Find(&members).Error
 	return members, err
 }
+
+// ListPage returns up to limit members with IDs greater than afterID, in ID order
+func (r *memberRepository) ListPage(ctx context.Context, afterID uint, limit int) ([]model.Member, error) {
+	var members []model.Member
+	err := r.db.WithContext(ctx).Where("id > ?", afterID).Order("id").Limit(limit).Find(&members).Error
+	return members, err
+}
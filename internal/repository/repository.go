@@ -3,7 +3,11 @@ package repository
 // Repositories aggregates all repository interfaces for DI
 
 type Repositories struct {
-	Member MemberRepository
+	Member      MemberRepository
+	Suppression SuppressionRepository
+	EmailAudit  EmailAuditRepository
+	Webhook     WebhookEventRepository
+	Campaign    CampaignRepository
 	// Add more repositories here as needed, e.g.:
 	// Product ProductRepository
 }
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"azureclient/internal/model"
+	"context"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SuppressionRepository interface {
+	Add(ctx context.Context, s *model.EmailSuppression) error
+	Remove(ctx context.Context, email string) error
+	Get(ctx context.Context, email string) (*model.EmailSuppression, error)
+	IsSuppressed(ctx context.Context, email string) (bool, error)
+	List(ctx context.Context) ([]model.EmailSuppression, error)
+}
+
+type suppressionRepository struct {
+	db *gorm.DB
+}
+
+func NewSuppressionRepository(db *gorm.DB) SuppressionRepository {
+	return &suppressionRepository{db: db}
+}
+
+// Add inserts the suppression, updating reason and detail if the address is already listed
+func (r *suppressionRepository) Add(ctx context.Context, s *model.EmailSuppression) error {
+	s.Email = normalizeEmail(s.Email)
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "email"}},
+		DoUpdates: clause.AssignmentColumns([]string{"reason", "detail", "message_id"}),
+	}).Create(s).Error
+}
+
+func (r *suppressionRepository) Remove(ctx context.Context, email string) error {
+	return r.db.WithContext(ctx).Where("email = ?", normalizeEmail(email)).Delete(&model.EmailSuppression{}).Error
+}
+
+func (r *suppressionRepository) Get(ctx context.Context, email string) (*model.EmailSuppression, error) {
+	var s model.EmailSuppression
+	err := r.db.WithContext(ctx).Where("email = ?", normalizeEmail(email)).First(&s).Error
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *suppressionRepository) IsSuppressed(ctx context.Context, email string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.EmailSuppression{}).Where("email = ?", normalizeEmail(email)).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *suppressionRepository) List(ctx context.Context) ([]model.EmailSuppression, error) {
+	var list []model.EmailSuppression
+	err := r.db.WithContext(ctx).Order("created_at desc").Find(&list).Error
+	return list, err
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"azureclient/internal/model"
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookEventRepository interface {
+	// MarkProcessed records the event and reports false if it was already recorded
+	MarkProcessed(ctx context.Context, event *model.WebhookEvent) (bool, error)
+	// Unmark forgets an event so a redelivery is processed again
+	Unmark(ctx context.Context, source, eventID string) error
+}
+
+type webhookEventRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookEventRepository(db *gorm.DB) WebhookEventRepository {
+	return &webhookEventRepository{db: db}
+}
+
+func (r *webhookEventRepository) MarkProcessed(ctx context.Context, event *model.WebhookEvent) (bool, error) {
+	res := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(event)
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *webhookEventRepository) Unmark(ctx context.Context, source, eventID string) error {
+	return r.db.WithContext(ctx).Where("source = ? AND event_id = ?", source, eventID).Delete(&model.WebhookEvent{}).Error
+}
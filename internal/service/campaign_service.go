@@ -0,0 +1,349 @@
+package service
+
+import (
+	"azureclient/client/azure"
+	"azureclient/internal/errs"
+	"azureclient/internal/model"
+	"azureclient/internal/otel"
+	"azureclient/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+)
+
+// campaignSaveInterval is how often a running campaign persists its progress
+const campaignSaveInterval = 5 * time.Second
+
+// errCampaignCancelled is the cancellation cause of a run stopped by CancelCampaign
+var errCampaignCancelled = errors.New("campaign cancelled")
+
+// Campaign is a persisted campaign with its progress per status; the
+// per-recipient results are listed by ListCampaignResults
+type Campaign struct {
+	model.EmailCampaign
+	Counts map[string]int `json:"counts"`
+}
+
+type CampaignService interface {
+	// StartCampaign sends a templated email to every member in the
+	// background and returns the persisted campaign. Templates can reference
+	// {{.ID}}, {{.Name}} and {{.Email}}.
+	StartCampaign(ctx context.Context, sender string, tmpl azure.EmailTemplate) (*Campaign, error)
+	// ResumeCampaign restarts an interrupted campaign, skipping recipients
+	// already sent
+	ResumeCampaign(ctx context.Context, id string) (*Campaign, error)
+	// CancelCampaign stops a running campaign; recipients already sent stay sent
+	CancelCampaign(ctx context.Context, id string) (*Campaign, error)
+	GetCampaign(ctx context.Context, id string) (*Campaign, error)
+	// ListCampaignResults returns the per-recipient results ordered by
+	// address, only those with status when it is not empty
+	ListCampaignResults(ctx context.Context, id, status string) ([]azure.BatchResult, error)
+	// RecoverCampaigns marks campaigns left running by a previous process as
+	// interrupted so they can be resumed, and returns how many there were
+	RecoverCampaigns(ctx context.Context) (int, error)
+	// Wait blocks until every background run has saved its outcome
+	Wait()
+}
+
+type campaignService struct {
+	ctx          context.Context // cancelled on shutdown, stopping every run
+	repos        repository.Repositories
+	sender       *azure.BatchSender
+	saveInterval time.Duration
+
+	mu      sync.Mutex
+	running map[string]*campaignRun
+	wg      sync.WaitGroup
+}
+
+// campaignRun is a campaign being sent in the background
+type campaignRun struct {
+	cancel context.CancelCauseFunc
+	done   chan struct{} // closed once the outcome is saved
+}
+
+// NewCampaignService creates a CampaignService whose background runs stop,
+// saving their progress as interrupted, when ctx is cancelled
+func NewCampaignService(ctx context.Context, repos repository.Repositories, sender *azure.BatchSender) CampaignService {
+	return &campaignService{
+		ctx:          ctx,
+		repos:        repos,
+		sender:       sender,
+		saveInterval: campaignSaveInterval,
+		running:      map[string]*campaignRun{},
+	}
+}
+
+func (s *campaignService) StartCampaign(ctx context.Context, sender string, tmpl azure.EmailTemplate) (*Campaign, error) {
+	ctx, span := otel.Tracer.Start(ctx, "StartCampaign")
+	defer span.End()
+	tmplJSON, err := json.Marshal(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	state := azure.NewCampaignState(tmpl.Name)
+	campaign := &model.EmailCampaign{ID: state.ID, Sender: sender, Template: string(tmplJSON)}
+	span.SetAttributes(attribute.String("campaign.id", state.ID), attribute.String("campaign.template", tmpl.Name))
+	if err := s.start(ctx, campaign, tmpl, state); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	return newCampaign(campaign, state), nil
+}
+
+func (s *campaignService) ResumeCampaign(ctx context.Context, id string) (*Campaign, error) {
+	ctx, span := otel.Tracer.Start(ctx, "ResumeCampaign")
+	defer span.End()
+	span.SetAttributes(attribute.String("campaign.id", id))
+	campaign, state, err := s.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if campaign.Status == model.CampaignCancelled {
+		return nil, errs.CampaignCancelled
+	}
+	var tmpl azure.EmailTemplate
+	if err := json.Unmarshal([]byte(campaign.Template), &tmpl); err != nil {
+		return nil, fmt.Errorf("decode campaign template: %w", err)
+	}
+	if err := s.start(ctx, campaign, tmpl, state); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	return newCampaign(campaign, state), nil
+}
+
+func (s *campaignService) CancelCampaign(ctx context.Context, id string) (*Campaign, error) {
+	ctx, span := otel.Tracer.Start(ctx, "CancelCampaign")
+	defer span.End()
+	span.SetAttributes(attribute.String("campaign.id", id))
+	s.mu.Lock()
+	run, ok := s.running[id]
+	s.mu.Unlock()
+	if ok {
+		run.cancel(errCampaignCancelled)
+		<-run.done
+		return s.GetCampaign(ctx, id)
+	}
+
+	campaign, state, err := s.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if campaign.Status == model.CampaignCompleted || campaign.Status == model.CampaignCancelled {
+		return nil, errs.CampaignNotRunning
+	}
+	// Failed and interrupted campaigns have no run to stop but may still be
+	// resumed; cancelling them rules that out
+	campaign.Status = model.CampaignCancelled
+	if err := s.repos.Campaign.Save(ctx, campaign); err != nil {
+		return nil, err
+	}
+	return newCampaign(campaign, state), nil
+}
+
+func (s *campaignService) GetCampaign(ctx context.Context, id string) (*Campaign, error) {
+	ctx, span := otel.Tracer.Start(ctx, "GetCampaign")
+	defer span.End()
+	span.SetAttributes(attribute.String("campaign.id", id))
+	campaign, state, err := s.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return newCampaign(campaign, state), nil
+}
+
+func (s *campaignService) ListCampaignResults(ctx context.Context, id, status string) ([]azure.BatchResult, error) {
+	ctx, span := otel.Tracer.Start(ctx, "ListCampaignResults")
+	defer span.End()
+	span.SetAttributes(attribute.String("campaign.id", id))
+	_, state, err := s.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	results := []azure.BatchResult{}
+	for _, r := range state.Snapshot() {
+		if status == "" || r.Status == status {
+			results = append(results, r)
+		}
+	}
+	slices.SortFunc(results, func(a, b azure.BatchResult) int { return strings.Compare(a.Address, b.Address) })
+	return results, nil
+}
+
+func (s *campaignService) RecoverCampaigns(ctx context.Context) (int, error) {
+	ctx, span := otel.Tracer.Start(ctx, "RecoverCampaigns")
+	defer span.End()
+	campaigns, err := s.repos.Campaign.ListByStatus(ctx, model.CampaignRunning)
+	if err != nil {
+		return 0, err
+	}
+	recovered := 0
+	for i := range campaigns {
+		campaign := &campaigns[i]
+		s.mu.Lock()
+		_, ok := s.running[campaign.ID]
+		s.mu.Unlock()
+		if ok {
+			continue
+		}
+		campaign.Status = model.CampaignInterrupted
+		if err := s.repos.Campaign.Save(ctx, campaign); err != nil {
+			return recovered, err
+		}
+		recovered++
+	}
+	span.SetAttributes(attribute.Int("campaign.recovered", recovered))
+	return recovered, nil
+}
+
+func (s *campaignService) Wait() {
+	s.wg.Wait()
+}
+
+// load reads a campaign and decodes its state
+func (s *campaignService) load(ctx context.Context, id string) (*model.EmailCampaign, *azure.CampaignState, error) {
+	campaign, err := s.repos.Campaign.Get(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, errs.NotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	state, err := decodeCampaignState(campaign)
+	if err != nil {
+		return nil, nil, err
+	}
+	return campaign, state, nil
+}
+
+// start lists the recipients and saves the campaign before handing it to a
+// background run, so every error up to that point reaches the caller
+func (s *campaignService) start(ctx context.Context, campaign *model.EmailCampaign, tmpl azure.EmailTemplate, state *azure.CampaignState) error {
+	// The campaign outlives the request that started it but not the service
+	runCtx, cancel := context.WithCancelCause(s.ctx)
+	s.mu.Lock()
+	if _, ok := s.running[campaign.ID]; ok {
+		s.mu.Unlock()
+		cancel(nil)
+		return errs.CampaignRunning
+	}
+	s.running[campaign.ID] = &campaignRun{cancel: cancel, done: make(chan struct{})}
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	members, err := s.repos.Member.List(ctx)
+	if err == nil {
+		campaign.Status = model.CampaignRunning
+		campaign.Error = ""
+		err = s.save(ctx, campaign, state)
+	}
+	if err != nil {
+		s.finish(campaign.ID)
+		return err
+	}
+	req := azure.BatchRequest{Sender: campaign.Sender, Template: tmpl}
+	for _, m := range members {
+		req.Recipients = append(req.Recipients, azure.BatchRecipient{
+			Address: m.Email,
+			Data:    map[string]any{"ID": m.ID, "Name": m.Name, "Email": m.Email},
+		})
+	}
+	go s.run(runCtx, *campaign, req, state)
+	return nil
+}
+
+// run sends the campaign, saving its progress every saveInterval and its
+// outcome when the send returns or is stopped
+func (s *campaignService) run(ctx context.Context, campaign model.EmailCampaign, req azure.BatchRequest, state *azure.CampaignState) {
+	defer s.finish(campaign.ID)
+	ctx, span := otel.Tracer.Start(ctx, "RunCampaign")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("campaign.id", campaign.ID),
+		attribute.String("campaign.template", req.Template.Name),
+		attribute.Int("campaign.recipients", len(req.Recipients)),
+	)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.sender.Send(ctx, req, state)
+		done <- err
+	}()
+	ticker := time.NewTicker(s.saveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.save(ctx, &campaign, state); err != nil {
+				log.Printf("Error saving campaign %s progress: %v", campaign.ID, err)
+			}
+		case err := <-done:
+			// A stopped run may still return nil when its last sends failed
+			// on the cancelled context, so check ctx first
+			switch {
+			case errors.Is(context.Cause(ctx), errCampaignCancelled):
+				campaign.Status = model.CampaignCancelled
+			case ctx.Err() != nil:
+				campaign.Status = model.CampaignInterrupted
+			case err == nil:
+				campaign.Status = model.CampaignCompleted
+			default:
+				span.RecordError(err)
+				campaign.Status = model.CampaignFailed
+				campaign.Error = err.Error()
+			}
+			// ctx may be cancelled already; the outcome must still be saved
+			saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+			defer cancel()
+			if err := s.save(saveCtx, &campaign, state); err != nil {
+				log.Printf("Error saving campaign %s: %v", campaign.ID, err)
+			}
+			return
+		}
+	}
+}
+
+func (s *campaignService) finish(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if run, ok := s.running[id]; ok {
+		run.cancel(nil)
+		close(run.done)
+		delete(s.running, id)
+		s.wg.Done()
+	}
+}
+
+func (s *campaignService) save(ctx context.Context, campaign *model.EmailCampaign, state *azure.CampaignState) error {
+	stateJSON, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	campaign.State = string(stateJSON)
+	return s.repos.Campaign.Save(ctx, campaign)
+}
+
+func decodeCampaignState(campaign *model.EmailCampaign) (*azure.CampaignState, error) {
+	state := &azure.CampaignState{}
+	if err := json.Unmarshal([]byte(campaign.State), state); err != nil {
+		return nil, fmt.Errorf("decode campaign state: %w", err)
+	}
+	if state.Results == nil {
+		state.Results = map[string]azure.BatchResult{}
+	}
+	return state, nil
+}
+
+func newCampaign(campaign *model.EmailCampaign, state *azure.CampaignState) *Campaign {
+	return &Campaign{EmailCampaign: *campaign, Counts: state.Counts()}
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"azureclient/client/azure"
+	"azureclient/internal/errs"
+	"azureclient/internal/model"
+	"azureclient/internal/repository"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type fakeMemberRepository struct {
+	repository.MemberRepository
+	members []model.Member
+	err     error
+}
+
+func (f *fakeMemberRepository) List(ctx context.Context) ([]model.Member, error) {
+	return f.members, f.err
+}
+
+type fakeCampaignRepository struct {
+	mu        sync.Mutex
+	campaigns map[string]model.EmailCampaign
+}
+
+func (f *fakeCampaignRepository) Save(ctx context.Context, campaign *model.EmailCampaign) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.campaigns[campaign.ID] = *campaign
+	return nil
+}
+
+func (f *fakeCampaignRepository) ListByStatus(ctx context.Context, status string) ([]model.EmailCampaign, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var campaigns []model.EmailCampaign
+	for _, c := range f.campaigns {
+		if c.Status == status {
+			campaigns = append(campaigns, c)
+		}
+	}
+	return campaigns, nil
+}
+
+func (f *fakeCampaignRepository) Get(ctx context.Context, id string) (*model.EmailCampaign, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	campaign, ok := f.campaigns[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &campaign, nil
+}
+
+// blockingEmailClient fails the first recipient and holds every send until
+// release is closed
+type blockingEmailClient struct {
+	release chan struct{}
+}
+
+func (c *blockingEmailClient) SendEmail(ctx context.Context, req azure.SendEmailRequest) error {
+	select {
+	case <-c.release:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if req.Recipient == "ada@example.com" {
+		return errors.New("mailbox unavailable")
+	}
+	return nil
+}
+
+func newTestCampaignService(members *fakeMemberRepository, client azure.ISendEmailClient) *campaignService {
+	return newTestCampaignServiceContext(context.Background(), members, client)
+}
+
+func newTestCampaignServiceContext(ctx context.Context, members *fakeMemberRepository, client azure.ISendEmailClient) *campaignService {
+	repos := repository.Repositories{
+		Member:   members,
+		Campaign: &fakeCampaignRepository{campaigns: map[string]model.EmailCampaign{}},
+	}
+	sender := azure.NewBatchSender(client, 1, 0, 0)
+	s := NewCampaignService(ctx, repos, sender).(*campaignService)
+	s.saveInterval = time.Millisecond
+	return s
+}
+
+// waitForCampaign waits until the run has saved its outcome and finished
+func waitForCampaign(t *testing.T, s *campaignService, id string) *Campaign {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		s.mu.Lock()
+		_, running := s.running[id]
+		s.mu.Unlock()
+		if running {
+			continue
+		}
+		campaign, err := s.GetCampaign(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return campaign
+	}
+	t.Fatal("campaign still running")
+	return nil
+}
+
+func TestStartCampaignReturnsMemberListError(t *testing.T) {
+	listErr := errors.New("connection refused")
+	s := newTestCampaignService(&fakeMemberRepository{err: listErr}, &blockingEmailClient{})
+	_, err := s.StartCampaign(context.Background(), "noreply@example.com", azure.EmailTemplate{Name: "welcome"})
+	if !errors.Is(err, listErr) {
+		t.Fatalf("err = %v, want %v", err, listErr)
+	}
+}
+
+func TestCampaignPersistsProgressAndResumes(t *testing.T) {
+	members := &fakeMemberRepository{members: []model.Member{
+		{ID: 1, Name: "Ada", Email: "ada@example.com"},
+		{ID: 2, Name: "Alan", Email: "alan@example.com"},
+	}}
+	client := &blockingEmailClient{release: make(chan struct{})}
+	s := newTestCampaignService(members, client)
+	tmpl := azure.EmailTemplate{Name: "welcome", Subject: "Hi {{.Name}}", PlainText: "Hello"}
+
+	started, err := s.StartCampaign(context.Background(), "noreply@example.com", tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if started.Status != model.CampaignRunning {
+		t.Fatalf("Status = %q, want running", started.Status)
+	}
+	if _, err := s.ResumeCampaign(context.Background(), started.ID); err != errs.CampaignRunning {
+		t.Fatalf("resume while running: err = %v, want %v", err, errs.CampaignRunning)
+	}
+	close(client.release)
+
+	done := waitForCampaign(t, s, started.ID)
+	if done.Status != model.CampaignCompleted || done.Counts[azure.BatchStatusSent] != 1 || done.Counts[azure.BatchStatusFailed] != 1 {
+		t.Fatalf("campaign = %+v", done)
+	}
+
+	// Resuming retries only the failed recipient
+	resumed, err := s.ResumeCampaign(context.Background(), started.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForCampaign(t, s, resumed.ID)
+	results, err := s.ListCampaignResults(context.Background(), started.ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Address != "ada@example.com" || results[0].Attempts != 2 || results[1].Attempts != 1 {
+		t.Fatalf("results = %+v, want ada retried once and alan sent once", results)
+	}
+	failed, err := s.ListCampaignResults(context.Background(), started.ID, azure.BatchStatusFailed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 1 || failed[0].Address != "ada@example.com" {
+		t.Fatalf("failed results = %+v", failed)
+	}
+}
+
+var campaignMembers = []model.Member{
+	{ID: 1, Name: "Ada", Email: "ada@example.com"},
+	{ID: 2, Name: "Alan", Email: "alan@example.com"},
+}
+
+func TestCancelCampaign(t *testing.T) {
+	s := newTestCampaignService(&fakeMemberRepository{members: campaignMembers}, &blockingEmailClient{release: make(chan struct{})})
+	started, err := s.StartCampaign(context.Background(), "noreply@example.com", azure.EmailTemplate{Name: "welcome", Subject: "Hi", PlainText: "Hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancelled, err := s.CancelCampaign(context.Background(), started.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelled.Status != model.CampaignCancelled {
+		t.Fatalf("Status = %q, want cancelled", cancelled.Status)
+	}
+	if _, err := s.CancelCampaign(context.Background(), started.ID); err != errs.CampaignNotRunning {
+		t.Fatalf("second cancel: err = %v, want %v", err, errs.CampaignNotRunning)
+	}
+	if _, err := s.ResumeCampaign(context.Background(), started.ID); err != errs.CampaignCancelled {
+		t.Fatalf("resume cancelled: err = %v, want %v", err, errs.CampaignCancelled)
+	}
+}
+
+func TestShutdownInterruptsCampaignsAndRecoveryMarksStaleOnes(t *testing.T) {
+	ctx, shutdown := context.WithCancel(context.Background())
+	members := &fakeMemberRepository{members: campaignMembers}
+	client := &blockingEmailClient{release: make(chan struct{})}
+	s := newTestCampaignServiceContext(ctx, members, client)
+	tmpl := azure.EmailTemplate{Name: "welcome", Subject: "Hi", PlainText: "Hello"}
+	started, err := s.StartCampaign(context.Background(), "noreply@example.com", tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shutdown()
+	waited := make(chan struct{})
+	go func() {
+		s.Wait()
+		close(waited)
+	}()
+	select {
+	case <-waited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait did not return after shutdown")
+	}
+	got, err := s.GetCampaign(context.Background(), started.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != model.CampaignInterrupted {
+		t.Fatalf("Status = %q, want interrupted", got.Status)
+	}
+
+	// A crash leaves the campaign running in the database; the next process
+	// marks it interrupted and can resume it
+	stale := got.EmailCampaign
+	stale.Status = model.CampaignRunning
+	s.repos.Campaign.Save(context.Background(), &stale)
+	next := newTestCampaignService(members, client)
+	next.repos = s.repos
+	if n, err := next.RecoverCampaigns(context.Background()); err != nil || n != 1 {
+		t.Fatalf("RecoverCampaigns() = %d, %v, want 1", n, err)
+	}
+	if got, _ := next.GetCampaign(context.Background(), started.ID); got.Status != model.CampaignInterrupted {
+		t.Fatalf("Status after recovery = %q, want interrupted", got.Status)
+	}
+	close(client.release)
+	if _, err := next.ResumeCampaign(context.Background(), started.ID); err != nil {
+		t.Fatal(err)
+	}
+	if done := waitForCampaign(t, next, started.ID); done.Status != model.CampaignCompleted {
+		t.Fatalf("Status after resume = %q, want completed", done.Status)
+	}
+}
+
+func TestGetCampaignNotFound(t *testing.T) {
+	s := newTestCampaignService(&fakeMemberRepository{}, &blockingEmailClient{})
+	if _, err := s.GetCampaign(context.Background(), "missing"); err != errs.NotFound {
+		t.Fatalf("err = %v, want %v", err, errs.NotFound)
+	}
+}
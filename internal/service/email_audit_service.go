@@ -0,0 +1,83 @@
+package service
+
+import (
+	"azureclient/client/azure"
+	"azureclient/internal/errs"
+	"azureclient/internal/model"
+	"azureclient/internal/otel"
+	"azureclient/internal/repository"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+)
+
+// AuditBodyPolicy controls whether message bodies are kept in the audit log
+type AuditBodyPolicy struct {
+	StoreBody bool
+	Retention time.Duration // bodies older than this are purged, 0 keeps them forever
+}
+
+type EmailAuditService interface {
+	azure.EmailAuditor
+	// ListAudits returns audit entries; a non-zero memberID restricts them to that member's address
+	ListAudits(ctx context.Context, memberID uint, filter model.EmailAuditFilter) ([]model.EmailAudit, error)
+	PurgeExpiredBodies(ctx context.Context) (int64, error)
+}
+
+type emailAuditService struct {
+	repos  repository.Repositories
+	policy AuditBodyPolicy
+}
+
+func NewEmailAuditService(repos repository.Repositories, policy AuditBodyPolicy) EmailAuditService {
+	return &emailAuditService{repos: repos, policy: policy}
+}
+
+func (s *emailAuditService) RecordEmail(ctx context.Context, entry azure.EmailAuditEntry) error {
+	audit := &model.EmailAudit{
+		RequestID:    entry.RequestID,
+		OperationID:  entry.OperationID,
+		Sender:       entry.Sender,
+		Recipient:    strings.ToLower(strings.TrimSpace(entry.Recipient)),
+		Subject:      entry.Subject,
+		TemplateName: entry.TemplateName,
+		Status:       entry.Status,
+		Error:        entry.Error,
+		DurationMs:   entry.Duration.Milliseconds(),
+	}
+	if s.policy.StoreBody {
+		audit.PlainText = entry.PlainText
+		audit.HTML = entry.HTML
+	}
+	return s.repos.EmailAudit.Create(ctx, audit)
+}
+
+func (s *emailAuditService) ListAudits(ctx context.Context, memberID uint, filter model.EmailAuditFilter) ([]model.EmailAudit, error) {
+	ctx, span := otel.Tracer.Start(ctx, "ListAudits")
+	defer span.End()
+	if memberID != 0 {
+		span.SetAttributes(attribute.Int("member.id", int(memberID)))
+		member, err := s.repos.Member.GetByID(ctx, memberID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errs.NotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		filter.Recipient = member.Email
+	}
+	return s.repos.EmailAudit.List(ctx, filter)
+}
+
+func (s *emailAuditService) PurgeExpiredBodies(ctx context.Context) (int64, error) {
+	if s.policy.Retention <= 0 {
+		return 0, nil
+	}
+	ctx, span := otel.Tracer.Start(ctx, "PurgeExpiredBodies")
+	defer span.End()
+	return s.repos.EmailAudit.PurgeBodies(ctx, time.Now().Add(-s.policy.Retention))
+}
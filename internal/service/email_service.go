@@ -0,0 +1,87 @@
+package service
+
+import (
+	"azureclient/client/azure"
+	"azureclient/internal/errs"
+	"azureclient/internal/otel"
+	"context"
+	"errors"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// EmailPreview is a rendered template and, when addresses are given, the
+// payload ACS would receive
+type EmailPreview struct {
+	Rendered azure.RenderedEmail     `json:"rendered"`
+	Payload  *azure.SendEmailPayload `json:"payload,omitempty"`
+}
+
+type EmailService interface {
+	SendEmail(ctx context.Context, req azure.SendEmailRequest) error
+	PreviewEmail(ctx context.Context, tmpl azure.EmailTemplate, data map[string]any, sender, recipient string) (*EmailPreview, error)
+}
+
+type emailService struct {
+	client azure.ISendEmailClient
+}
+
+func NewEmailService(client azure.ISendEmailClient) EmailService {
+	return &emailService{client: client}
+}
+
+func (s *emailService) SendEmail(ctx context.Context, req azure.SendEmailRequest) error {
+	ctx, span := otel.Tracer.Start(ctx, "SendEmail")
+	defer span.End()
+	span.SetAttributes(attribute.String("email.recipient", req.Recipient))
+	if err := s.client.SendEmail(ctx, req); err != nil {
+		span.RecordError(err)
+		return toAppError(err)
+	}
+	return nil
+}
+
+func (s *emailService) PreviewEmail(ctx context.Context, tmpl azure.EmailTemplate, data map[string]any, sender, recipient string) (*EmailPreview, error) {
+	_, span := otel.Tracer.Start(ctx, "PreviewEmail")
+	defer span.End()
+	span.SetAttributes(attribute.String("email.template", tmpl.Name))
+	rendered, err := tmpl.Render(data)
+	if err != nil {
+		return nil, &errs.AppError{Status: errs.BadRequest.Status, Message: err.Error(), Code: errs.BadRequest.Code}
+	}
+	preview := &EmailPreview{Rendered: rendered}
+	if sender == "" || recipient == "" {
+		return preview, nil
+	}
+	payload, err := azure.BuildPayload(azure.SendEmailRequest{
+		Sender:    sender,
+		Recipient: recipient,
+		Subject:   rendered.Subject,
+		PlainText: rendered.PlainText,
+		HTML:      rendered.HTML,
+	})
+	if err != nil {
+		return nil, &errs.AppError{Status: errs.BadRequest.Status, Message: err.Error(), Code: errs.BadRequest.Code}
+	}
+	preview.Payload = payload
+	return preview, nil
+}
+
+// toAppError maps ACS email errors onto the application error set. Only
+// throttling is passed through; any other ACS failure, including a 4xx
+// caused by our own key or endpoint, is a delivery failure rather than a
+// problem with the caller's request.
+func toAppError(err error) error {
+	if errors.Is(err, azure.ErrRecipientSuppressed) {
+		return errs.EmailSuppressed
+	}
+	var emailErr *azure.EmailError
+	if !errors.As(err, &emailErr) {
+		return err
+	}
+	if emailErr.StatusCode == http.StatusTooManyRequests {
+		return errs.TooManyRequests
+	}
+	return errs.EmailFailed
+}
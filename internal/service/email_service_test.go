@@ -0,0 +1,33 @@
+package service
+
+import (
+	"azureclient/client/azure"
+	"azureclient/internal/errs"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestToAppError(t *testing.T) {
+	plain := errors.New("invalid sender address")
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"throttled", &azure.EmailError{StatusCode: http.StatusTooManyRequests}, errs.TooManyRequests},
+		{"bad request from ACS", &azure.EmailError{StatusCode: http.StatusBadRequest, Message: "bad"}, errs.EmailFailed},
+		{"bad access key", &azure.EmailError{StatusCode: http.StatusUnauthorized}, errs.EmailFailed},
+		{"forbidden", &azure.EmailError{StatusCode: http.StatusForbidden}, errs.EmailFailed},
+		{"unknown resource", &azure.EmailError{StatusCode: http.StatusNotFound}, errs.EmailFailed},
+		{"server error", fmt.Errorf("send: %w", &azure.EmailError{StatusCode: http.StatusServiceUnavailable}), errs.EmailFailed},
+		{"suppressed", fmt.Errorf("send: %w", azure.ErrRecipientSuppressed), errs.EmailSuppressed},
+		{"not an ACS error", plain, plain},
+	}
+	for _, tt := range tests {
+		if got := toAppError(tt.err); got != tt.want {
+			t.Errorf("%s: toAppError() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -6,8 +6,10 @@ import (
 	"azureclient/internal/otel"
 	"azureclient/internal/repository"
 	"context"
+	"errors"
 
 	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
 )
 
 type MemberService interface {
@@ -16,6 +18,9 @@ type MemberService interface {
 	UpdateMember(ctx context.Context, member *model.Member) error
 	DeleteMember(ctx context.Context, id uint) error
 	ListMembers(ctx context.Context) ([]model.Member, error)
+	// ListMembersPage returns up to limit members after the cursor ID and
+	// the cursor for the next page, 0 when there are no more
+	ListMembersPage(ctx context.Context, afterID uint, limit int) ([]model.Member, uint, error)
 }
 
 type memberService struct {
@@ -42,7 +47,11 @@ func (s *memberService) GetMemberByID(ctx context.Context, id uint) (*model.Memb
 		span.RecordError(errs.UnableToProceed)
 		return nil, errs.UnableToProceed
 	}
-	return s.repos.Member.GetByID(ctx, id)
+	member, err := s.repos.Member.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errs.NotFound
+	}
+	return member, err
 }
 
 func (s *memberService) UpdateMember(ctx context.Context, member *model.Member) error {
@@ -64,3 +73,19 @@ func (s *memberService) ListMembers(ctx context.Context) ([]model.Member, error)
 	defer span.End()
 	return s.repos.Member.List(ctx)
 }
+
+func (s *memberService) ListMembersPage(ctx context.Context, afterID uint, limit int) ([]model.Member, uint, error) {
+	ctx, span := otel.Tracer.Start(ctx, "ListMembersPage")
+	defer span.End()
+	span.SetAttributes(attribute.Int("page.after", int(afterID)), attribute.Int("page.limit", limit))
+	// Fetch one extra row to learn whether another page exists
+	members, err := s.repos.Member.ListPage(ctx, afterID, limit+1)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(members) <= limit {
+		return members, 0, nil
+	}
+	members = members[:limit]
+	return members, members[limit-1].ID, nil
+}
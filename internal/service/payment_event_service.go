@@ -0,0 +1,83 @@
+package service
+
+import (
+	http_client "azureclient/client/http"
+	"azureclient/internal/model"
+	"azureclient/internal/otel"
+	"azureclient/internal/repository"
+	"context"
+	"errors"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const paymentWebhookSource = "payment"
+
+// PaymentEventHandler reacts to one payment event type
+type PaymentEventHandler func(ctx context.Context, event *http_client.PaymentEvent) error
+
+type PaymentEventService interface {
+	// Register adds a handler for eventType; "*" receives every event
+	Register(eventType string, handler PaymentEventHandler)
+	// HandleEvent dispatches a verified event once, ignoring redeliveries
+	HandleEvent(ctx context.Context, event *http_client.PaymentEvent) error
+}
+
+type paymentEventService struct {
+	repos repository.Repositories
+
+	mu       sync.RWMutex
+	handlers map[string][]PaymentEventHandler
+}
+
+func NewPaymentEventService(repos repository.Repositories) PaymentEventService {
+	return &paymentEventService{repos: repos, handlers: map[string][]PaymentEventHandler{}}
+}
+
+func (s *paymentEventService) Register(eventType string, handler PaymentEventHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[eventType] = append(s.handlers[eventType], handler)
+}
+
+func (s *paymentEventService) HandleEvent(ctx context.Context, event *http_client.PaymentEvent) error {
+	ctx, span := otel.Tracer.Start(ctx, "HandlePaymentEvent")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("payment.event_id", event.ID),
+		attribute.String("payment.event_type", event.Type),
+	)
+
+	first, err := s.repos.Webhook.MarkProcessed(ctx, &model.WebhookEvent{
+		Source:  paymentWebhookSource,
+		EventID: event.ID,
+		Type:    event.Type,
+	})
+	if err != nil {
+		return err
+	}
+	if !first {
+		span.SetAttributes(attribute.Bool("payment.event_duplicate", true))
+		return nil
+	}
+
+	s.mu.RLock()
+	handlers := append(append([]PaymentEventHandler(nil), s.handlers[event.Type]...), s.handlers["*"]...)
+	s.mu.RUnlock()
+	var errs []error
+	for _, h := range handlers {
+		if err := h(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		span.RecordError(err)
+		// Let the provider redeliver so failed handlers get another chance
+		if unmarkErr := s.repos.Webhook.Unmark(context.WithoutCancel(ctx), paymentWebhookSource, event.ID); unmarkErr != nil {
+			return errors.Join(err, unmarkErr)
+		}
+		return err
+	}
+	return nil
+}
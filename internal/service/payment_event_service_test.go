@@ -0,0 +1,93 @@
+package service
+
+import (
+	http_client "azureclient/client/http"
+	"azureclient/internal/model"
+	"azureclient/internal/repository"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// fakeWebhookEventRepository keeps processed event IDs in memory
+type fakeWebhookEventRepository struct {
+	mu        sync.Mutex
+	processed map[string]bool
+	unmarked  int
+}
+
+func (f *fakeWebhookEventRepository) MarkProcessed(ctx context.Context, event *model.WebhookEvent) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := event.Source + "/" + event.EventID
+	if f.processed[key] {
+		return false, nil
+	}
+	f.processed[key] = true
+	return true, nil
+}
+
+func (f *fakeWebhookEventRepository) Unmark(ctx context.Context, source, eventID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.processed, source+"/"+eventID)
+	f.unmarked++
+	return nil
+}
+
+func TestHandlePaymentEvent(t *testing.T) {
+	handlerErr := errors.New("handler failed")
+	tests := []struct {
+		name         string
+		results      []error // handler result per delivery
+		wantCalls    int
+		wantErrs     []error
+		wantUnmarked int
+	}{
+		{
+			name:      "redelivery is skipped",
+			results:   []error{nil, nil},
+			wantCalls: 1,
+			wantErrs:  []error{nil, nil},
+		},
+		{
+			name:         "failed handler lets the retry through",
+			results:      []error{handlerErr, nil, nil},
+			wantCalls:    2,
+			wantErrs:     []error{handlerErr, nil, nil},
+			wantUnmarked: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webhooks := &fakeWebhookEventRepository{processed: map[string]bool{}}
+			s := NewPaymentEventService(repository.Repositories{Webhook: webhooks})
+			calls, other := 0, 0
+			s.Register(http_client.EventPaymentSucceeded, func(ctx context.Context, event *http_client.PaymentEvent) error {
+				calls++
+				return tt.results[calls-1]
+			})
+			s.Register(http_client.EventRefundSucceeded, func(ctx context.Context, event *http_client.PaymentEvent) error {
+				other++
+				return nil
+			})
+
+			event := &http_client.PaymentEvent{ID: "evt_1", Type: http_client.EventPaymentSucceeded}
+			for i, want := range tt.wantErrs {
+				if err := s.HandleEvent(context.Background(), event); !errors.Is(err, want) {
+					t.Fatalf("delivery %d: err = %v, want %v", i+1, err, want)
+				}
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("handler calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if other != 0 {
+				t.Errorf("handler for another event type called %d times", other)
+			}
+			if webhooks.unmarked != tt.wantUnmarked {
+				t.Errorf("Unmark calls = %d, want %d", webhooks.unmarked, tt.wantUnmarked)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"azureclient/internal/errs"
+	"azureclient/internal/model"
+	"azureclient/internal/otel"
+	"azureclient/internal/repository"
+	"context"
+	"errors"
+	"net/mail"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+)
+
+// DeliveryReport is the part of an ACS delivery report event we act on
+type DeliveryReport struct {
+	MessageID     string
+	Recipient     string
+	Status        string
+	StatusMessage string
+}
+
+type SuppressionService interface {
+	AddSuppression(ctx context.Context, s *model.EmailSuppression) error
+	RemoveSuppression(ctx context.Context, email string) error
+	GetSuppression(ctx context.Context, email string) (*model.EmailSuppression, error)
+	ListSuppressions(ctx context.Context) ([]model.EmailSuppression, error)
+	HandleDeliveryReport(ctx context.Context, report DeliveryReport) error
+}
+
+type suppressionService struct {
+	repos repository.Repositories
+}
+
+func NewSuppressionService(repos repository.Repositories) SuppressionService {
+	return &suppressionService{repos: repos}
+}
+
+func (s *suppressionService) AddSuppression(ctx context.Context, sup *model.EmailSuppression) error {
+	ctx, span := otel.Tracer.Start(ctx, "AddSuppression")
+	defer span.End()
+	if _, err := mail.ParseAddress(sup.Email); err != nil {
+		return errs.BadRequest
+	}
+	if sup.Reason == "" {
+		sup.Reason = model.SuppressionManual
+	}
+	span.SetAttributes(attribute.String("suppression.reason", sup.Reason))
+	return s.repos.Suppression.Add(ctx, sup)
+}
+
+func (s *suppressionService) RemoveSuppression(ctx context.Context, email string) error {
+	ctx, span := otel.Tracer.Start(ctx, "RemoveSuppression")
+	defer span.End()
+	return s.repos.Suppression.Remove(ctx, email)
+}
+
+func (s *suppressionService) GetSuppression(ctx context.Context, email string) (*model.EmailSuppression, error) {
+	ctx, span := otel.Tracer.Start(ctx, "GetSuppression")
+	defer span.End()
+	sup, err := s.repos.Suppression.Get(ctx, email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errs.NotFound
+	}
+	return sup, err
+}
+
+func (s *suppressionService) ListSuppressions(ctx context.Context) ([]model.EmailSuppression, error) {
+	ctx, span := otel.Tracer.Start(ctx, "ListSuppressions")
+	defer span.End()
+	return s.repos.Suppression.List(ctx)
+}
+
+// HandleDeliveryReport suppresses the recipient on hard bounces; other
+// statuses are ignored. ACS delivery reports carry no spam complaints, so
+// complaint suppressions can only be added through AddSuppression.
+func (s *suppressionService) HandleDeliveryReport(ctx context.Context, report DeliveryReport) error {
+	ctx, span := otel.Tracer.Start(ctx, "HandleDeliveryReport")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("email.message_id", report.MessageID),
+		attribute.String("email.delivery_status", report.Status),
+	)
+	reason := suppressionReason(report.Status)
+	if reason == "" || report.Recipient == "" {
+		return nil
+	}
+	return s.repos.Suppression.Add(ctx, &model.EmailSuppression{
+		Email:     report.Recipient,
+		Reason:    reason,
+		Detail:    report.StatusMessage,
+		MessageID: report.MessageID,
+	})
+}
+
+// suppressionReason maps ACS delivery statuses to a suppression reason.
+// Only Bounced is a permanent rejection by the recipient's server; Failed
+// also covers sender-side and transient problems, so it never suppresses.
+func suppressionReason(status string) string {
+	switch strings.ToLower(status) {
+	case "bounced":
+		return model.SuppressionHardBounce
+	default:
+		return ""
+	}
+}
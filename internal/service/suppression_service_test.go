@@ -0,0 +1,26 @@
+package service
+
+import (
+	"azureclient/internal/model"
+	"testing"
+)
+
+func TestSuppressionReason(t *testing.T) {
+	tests := []struct {
+		status string
+		want   string
+	}{
+		{"Bounced", model.SuppressionHardBounce},
+		{"Failed", ""},
+		{"Delivered", ""},
+		{"Quarantined", ""},
+		{"FilteredSpam", ""},
+		{"Suppressed", ""},
+		{"Expanded", ""},
+	}
+	for _, tt := range tests {
+		if got := suppressionReason(tt.status); got != tt.want {
+			t.Errorf("suppressionReason(%q) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
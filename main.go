@@ -5,12 +5,18 @@ import (
 	http_client "azureclient/client/http"
 	"azureclient/config"
 	"azureclient/internal/controller"
+	"azureclient/internal/model"
 	"azureclient/internal/repository"
 	"azureclient/internal/service"
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"azureclient/internal/otel"
 
@@ -37,6 +43,10 @@ func main() {
 			log.Printf("Error shutting down tracer provider: %v", err)
 		}
 	}()
+	// appCtx is cancelled on SIGINT or SIGTERM and bounds background work
+	appCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
@@ -54,32 +64,152 @@ func main() {
 		log.Fatalf("Failed to register GORM OpenTelemetry plugin: %v", err)
 	}
 
+	if err := db.AutoMigrate(&model.EmailSuppression{}, &model.EmailAudit{}, &model.WebhookEvent{}, &model.EmailCampaign{}); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
 	// DI: Repositories struct, Service, Controller
 	repos := repository.Repositories{
-		Member: repository.NewMemberRepository(db),
+		Member:      repository.NewMemberRepository(db),
+		Suppression: repository.NewSuppressionRepository(db),
+		EmailAudit:  repository.NewEmailAuditRepository(db),
+		Webhook:     repository.NewWebhookEventRepository(db),
+		Campaign:    repository.NewCampaignRepository(db),
 		// Add more repositories here as needed
 	}
 	memberService := service.NewMemberService(repos)
 	memberController := controller.NewMemberController(memberService)
+	suppressionService := service.NewSuppressionService(repos)
+	suppressionController := controller.NewSuppressionController(suppressionService, cfg.Email.EventsSecret)
+	if cfg.Email.EventsSecret == "" {
+		log.Printf("EMAIL_EVENTS_SECRET is not set; /email/events is disabled")
+	}
 
 	// Set up Gorilla Mux router
 	r := mux.NewRouter()
 	memberController.RegisterRoutes(r)
+	suppressionController.RegisterRoutes(r)
+
+	client, err := azure.NewAzureClient(cfg.Azure.StorageAccount, cfg.Azure.EmailEndpoint, cfg.Azure.EmailAccessKey)
+	if err != nil {
+		log.Fatalf("Failed to create AzureClient: %v", err)
+	}
+	// Select email provider; the capture provider exposes its inbox on the router
+	switch cfg.Email.Provider {
+	case "smtp":
+		client.SendEmailClient = azure.NewSMTPEmailClient(cfg.Email.SMTPHost, cfg.Email.SMTPPort,
+			cfg.Email.SMTPUsername, cfg.Email.SMTPPassword, cfg.Email.SMTPAuth, cfg.Email.SMTPStartTLS)
+	case "acs":
+		if acsClient, ok := client.SendEmailClient.(*azure.SendEmailClient); ok {
+			acsClient.DryRun = cfg.Email.DryRun
+		}
+	case "capture":
+		captureClient := azure.NewCaptureEmailClient()
+		client.SendEmailClient = captureClient
+		r.PathPrefix("/dev/mail").Handler(captureClient.Handler("/dev/mail"))
+	}
+	client.SendEmailClient = azure.NewSuppressingEmailClient(client.SendEmailClient, repos.Suppression)
+	emailAuditService := service.NewEmailAuditService(repos, service.AuditBodyPolicy{
+		StoreBody: cfg.Email.AuditStoreBody,
+		Retention: time.Duration(cfg.Email.AuditBodyRetentionDays) * 24 * time.Hour,
+	})
+	client.SendEmailClient = azure.NewAuditingEmailClient(client.SendEmailClient, emailAuditService)
+	client.SendEmailClient = azure.NewIdempotentEmailClient(client.SendEmailClient, cfg.Email.IdempotencyWindow)
+	controller.NewEmailAuditController(emailAuditService).RegisterRoutes(r)
+	go func() {
+		for range time.Tick(time.Hour) {
+			if _, err := emailAuditService.PurgeExpiredBodies(context.Background()); err != nil {
+				log.Printf("Error purging email audit bodies: %v", err)
+			}
+		}
+	}()
+	batchSender := azure.NewBatchSender(client.SendEmailClient, cfg.Email.Workers, cfg.Email.RatePerMinute, cfg.Email.Burst)
+	campaignService := service.NewCampaignService(appCtx, repos, batchSender)
+	if n, err := campaignService.RecoverCampaigns(appCtx); err != nil {
+		log.Printf("Error recovering campaigns: %v", err)
+	} else if n > 0 {
+		log.Printf("Marked %d campaigns left running by a previous process as interrupted", n)
+	}
+	controller.NewCampaignController(campaignService).RegisterRoutes(r)
+	emailService := service.NewEmailService(client.SendEmailClient)
+	controller.NewEmailController(emailService).RegisterRoutes(r)
+
+	// Payment webhooks; register handlers per event type with paymentEventService.Register
+	paymentEventService := service.NewPaymentEventService(repos)
+	paymentEventService.Register("*", func(ctx context.Context, event *http_client.PaymentEvent) error {
+		log.Printf("Payment event %s received: %s", event.ID, event.Type)
+		return nil
+	})
+	controller.NewPaymentWebhookController(paymentEventService, cfg.Client.PaymentWebhookSecret,
+		cfg.Client.PaymentWebhookTolerance).RegisterRoutes(r)
+	if cfg.Client.PaymentWebhookSecret == "" {
+		log.Printf("PAYMENT_WEBHOOK_SECRET is not set; /webhooks/payment is disabled")
+	}
+
+	// Downstream HTTP clients; circuit states are exposed for health checks
+	paymentClient := http_client.NewPaymentClient(cfg.Client.PaymentBaseURL)
+	memberClient := http_client.NewMemberClient(cfg.Client.MemberBaseURL)
+	paymentTransport, err := newTransport(cfg.Client.PaymentTransport)
+	if err != nil {
+		log.Fatalf("Failed to configure payment client transport: %v", err)
+	}
+	paymentClient.WithTransport(paymentTransport)
+	memberTransport, err := newTransport(cfg.Client.MemberTransport)
+	if err != nil {
+		log.Fatalf("Failed to configure member client transport: %v", err)
+	}
+	memberClient.WithTransport(memberTransport)
+	// Fault injection sits next to the network, so it is installed first
+	faultRules, err := http_client.ParseFaultRules(cfg.Client.Faults.Rules)
+	if err != nil {
+		log.Fatalf("Failed to parse FAULT_INJECTION_RULES: %v", err)
+	}
+	faults := http_client.NewFaultInjector(cfg.Client.Faults.Enabled, faultRules...)
+	paymentClient.WithFaultInjector(faults)
+	memberClient.WithFaultInjector(faults)
+	if cfg.Client.Faults.Admin {
+		r.Handle("/admin/faults", faults.AdminHandler(cfg.Client.Faults.AdminToken)).Methods("GET", "PUT", "DELETE")
+	}
+	paymentAuth, err := newAuthenticator(cfg.Client.PaymentAuth)
+	if err != nil {
+		log.Fatalf("Failed to configure payment client auth: %v", err)
+	}
+	paymentClient.WithAuth(paymentAuth)
+	memberAuth, err := newAuthenticator(cfg.Client.MemberAuth)
+	if err != nil {
+		log.Fatalf("Failed to configure member client auth: %v", err)
+	}
+	memberClient.WithAuth(memberAuth)
+	paymentClient.WithLimiter(newLimiter(cfg.Client.PaymentLimits))
+	memberClient.WithLimiter(newLimiter(cfg.Client.MemberLimits))
+	// Outer member middlewares, innermost first: hedging, cache, singleflight
+	if cfg.Client.MemberHedging {
+		hedge := http_client.DefaultHedgeSettings()
+		hedge.Delay = cfg.Client.MemberHedgeDelay
+		memberClient.WithHedging(hedge)
+	}
+	switch cfg.Client.MemberCache.Store {
+	case "memory":
+		memberClient.WithCache(http_client.NewLRUCacheStore(cfg.Client.MemberCache.MaxEntries))
+	case "blob":
+		memberClient.WithCache(azure.NewBlobCacheStore(client.BlobClient, cfg.Client.MemberCache.Container, "member/"))
+	}
+	if cfg.Client.MemberSingleflight {
+		memberClient.WithSingleflight()
+	}
+	r.Handle("/health/circuits/payment", paymentClient.CircuitBreaker.HealthHandler()).Methods("GET")
+	r.Handle("/health/circuits/member", memberClient.CircuitBreaker.HealthHandler()).Methods("GET")
 
 	// Start HTTP server
+	server := &http.Server{Addr: ":8080", Handler: r}
 	go func() {
 		fmt.Println("HTTP server started on :8080")
-		if err := http.ListenAndServe(":8080", r); err != nil {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Fatalf("Failed to start HTTP server: %v", err)
 		}
 	}()
 
 	// --- Existing Azure/Client logic below ---
-	client, err := azure.NewAzureClient(cfg.Azure.StorageAccount, cfg.Azure.EmailEndpoint, cfg.Azure.EmailAccessKey)
-	if err != nil {
-		log.Fatalf("Failed to create AzureClient: %v", err)
-	}
-
 	ctx := context.Background()
 	// Example: Upload a blob
 	err = client.BlobClient.UploadBlob(ctx, "test-container", "test-blob.txt", []byte("Hello from AzureClient!"))
@@ -105,26 +235,101 @@ func main() {
 		PlainText: "Hello from AzureClient Email!",
 		HTML:      "<b>Hello from AzureClient Email!</b>",
 	}
-	err = client.SendEmailClient.SendEmail(ctx, emailReq)
-	if err != nil {
+	err = emailService.SendEmail(ctx, emailReq)
+	var dryRun *azure.DryRunError
+	switch {
+	case errors.As(err, &dryRun):
+		fmt.Printf("Email validated but not sent (dry run): subject %q to %d recipient(s).\n",
+			dryRun.Payload.Content.Subject, len(dryRun.Payload.Recipients.To))
+	case err != nil:
 		fmt.Println("SendEmail error:", err)
-	} else {
+	default:
 		fmt.Println("Email sent successfully.")
 	}
 
 	// Example: PaymentClient usage
-	paymentClient := http_client.NewPaymentClient(cfg.Client.PaymentBaseURL)
-	if err := paymentClient.DoSomething(ctx); err != nil {
+	if txns, err := paymentClient.ListTransactions(ctx, http_client.ListTransactionsRequest{Limit: 10}); err != nil {
 		fmt.Println("PaymentClient error:", err)
 	} else {
-		fmt.Println("PaymentClient call succeeded.")
+		fmt.Printf("PaymentClient listed %d transactions.\n", len(txns.Data))
 	}
 
 	// Example: MemberClient usage
-	memberClient := http_client.NewMemberClient(cfg.Client.MemberBaseURL)
-	if err := memberClient.DoSomething(ctx); err != nil {
+	members := 0
+	it := memberClient.Members(50)
+	for it.Next(ctx) {
+		members++
+	}
+	if err := it.Err(); err != nil {
 		fmt.Println("MemberClient error:", err)
 	} else {
-		fmt.Println("MemberClient call succeeded.")
+		fmt.Printf("MemberClient listed %d members.\n", members)
+	}
+
+	// Serve until interrupted, then let running campaigns save their progress
+	<-appCtx.Done()
+	log.Println("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error shutting down HTTP server: %v", err)
+	}
+	campaignService.Wait()
+}
+
+// newAuthenticator builds the downstream authenticator selected by cfg; nil for "none"
+func newAuthenticator(cfg config.AuthConfig) (http_client.Authenticator, error) {
+	switch cfg.Type {
+	case "apikey":
+		return http_client.NewAPIKeyAuth(cfg.APIKeyHeader, cfg.APIKey), nil
+	case "bearer":
+		return http_client.NewBearerAuth(http_client.StaticToken(cfg.Token)), nil
+	case "oauth2":
+		return http_client.NewOAuth2Auth(http_client.ClientCredentialsConfig{
+			TokenURL:     cfg.TokenURL,
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			Scopes:       cfg.Scopes,
+		}), nil
+	case "azure":
+		return http_client.NewAzureADAuth(cfg.Scopes...)
+	}
+	return nil, nil
+}
+
+// newLimiter builds a client-side limiter from cfg; a zero burst defaults
+// to a tenth of the rate
+func newLimiter(cfg config.LimitConfig) *http_client.Limiter {
+	settings := http_client.DefaultLimiterSettings(cfg.RatePerSecond, cfg.MaxInFlight)
+	if cfg.Burst > 0 {
+		settings.Burst = cfg.Burst
+	}
+	settings.FailFast = cfg.FailFast
+	return http_client.NewLimiter(settings)
+}
+
+// newTransport builds a client transport from cfg on top of the defaults
+func newTransport(cfg config.TransportConfig) (*http.Transport, error) {
+	opts := http_client.DefaultTransportOptions()
+	opts.CAFile = cfg.CAFile
+	opts.CertFile = cfg.CertFile
+	opts.KeyFile = cfg.KeyFile
+	opts.CertReloadInterval = cfg.CertReloadInterval
+	opts.ProxyURL = cfg.ProxyURL
+	opts.MaxConnsPerHost = cfg.MaxConnsPerHost
+	opts.ResponseHeaderTimeout = cfg.ResponseHeaderTimeout
+	opts.DisableHTTP2 = !cfg.HTTP2
+	if cfg.MaxIdleConnsPerHost > 0 {
+		opts.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	}
+	if cfg.IdleConnTimeout > 0 {
+		opts.IdleConnTimeout = cfg.IdleConnTimeout
+	}
+	if cfg.DialTimeout > 0 {
+		opts.DialTimeout = cfg.DialTimeout
+	}
+	if cfg.TLSHandshakeTimeout > 0 {
+		opts.TLSHandshakeTimeout = cfg.TLSHandshakeTimeout
 	}
+	return http_client.NewTransport(opts)
 }